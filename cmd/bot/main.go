@@ -2,17 +2,27 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/LLionNg/shopee-livestream-bot/internal/auth"
 	"github.com/LLionNg/shopee-livestream-bot/internal/browser"
+	"github.com/LLionNg/shopee-livestream-bot/internal/cart"
+	"github.com/LLionNg/shopee-livestream-bot/internal/checkout"
+	"github.com/LLionNg/shopee-livestream-bot/internal/cli"
 	"github.com/LLionNg/shopee-livestream-bot/internal/config"
+	"github.com/LLionNg/shopee-livestream-bot/internal/console"
+	"github.com/LLionNg/shopee-livestream-bot/internal/evidence"
 	"github.com/LLionNg/shopee-livestream-bot/internal/livestream"
+	"github.com/LLionNg/shopee-livestream-bot/internal/notify"
 	"github.com/LLionNg/shopee-livestream-bot/internal/purchase"
+	"github.com/LLionNg/shopee-livestream-bot/internal/selectors"
+	"github.com/LLionNg/shopee-livestream-bot/internal/shutdown"
 	"github.com/LLionNg/shopee-livestream-bot/pkg/logger"
 )
 
@@ -22,74 +32,316 @@ const (
 )
 
 func main() {
-	// Print banner
-	printBanner()
+	configPath := flag.String("config", "configs/config.yaml", "path to configuration file")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), cli.Usage())
+	}
+	flag.Parse()
+
+	// Dispatch subcommands; no command (or "run") starts the bot
+	args := flag.Args()
+	if len(args) > 0 && args[0] != "run" {
+		if err := cli.Run(*configPath, args); err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	tui := false
+	if len(args) > 0 {
+		fs := flag.NewFlagSet("run", flag.ExitOnError)
+		fs.BoolVar(&tui, "tui", false, "show a live dashboard instead of log lines")
+		fs.Parse(args[1:])
+	}
+
+	run(*configPath, tui)
+}
+
+// run starts the bot and monitors livestreams until interrupted
+func run(configPath string, tui bool) {
+	// Everything goes through the console: plain lines on a terminal (or the
+	// dashboard with --tui), structured records when output is redirected
+	terminal := console.IsTerminal(os.Stdout)
+	log := logger.NewWriter(console.Writer(), "info", true)
+	if terminal {
+		printBanner()
+	} else {
+		log = logger.New("info", true)
+		console.SetOutput(console.Structured(log.Slog()))
+	}
+	if tui && !(terminal && console.IsTerminal(os.Stdin)) {
+		log.Warn("The dashboard needs a terminal, logging instead")
+		tui = false
+	}
 
 	// Initialize logger
-	log := logger.New("info", true)
 	log.Info("Starting Shopee Livestream Bot...")
 
 	// Load configuration
-	cfg, err := config.Load("configs/config.yaml")
+	cfg, err := config.Load(configPath)
 	if err != nil {
 		log.Fatal("Failed to load configuration", "error", err)
 	}
-	log.Info("Configuration loaded successfully")
+	log.Info("Configuration loaded successfully", "region", cfg.Shopee.Region)
 
-	// Create context with cancellation
+	// Load selector profile
+	sel, err := selectors.NewStore(cfg.Shopee.SelectorsFile, cfg.Shopee.GetRegion().Texts)
+	if err != nil {
+		log.Fatal("Failed to load selector profile", "error", err)
+	}
+	log.Info("Selector profile loaded", "path", sel.Path(), "version", sel.Profile().Version)
+	if r := sel.Profile().Region; r != "" && r != cfg.Shopee.Region {
+		log.Warn("Selector profile was written for a different region", "profile_region", sel.Profile().Region, "region", cfg.Shopee.Region)
+	}
+
+	// Work is cancelled first on shutdown; the browser outlives it so the
+	// session can still be saved before Chrome closes
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
+	browserRoot, closeBrowser := context.WithCancel(context.Background())
+	defer closeBrowser()
+
+	// Background work, so shutdown can wait for it and report what's left
+	tasks := &shutdown.Group{}
 
 	// Setup graceful shutdown
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 
+	// Reload selector profile on SIGHUP
+	reloadChan := make(chan os.Signal, 1)
+	signal.Notify(reloadChan, syscall.SIGHUP)
+	go func() {
+		for range reloadChan {
+			if err := sel.Reload(); err != nil {
+				log.Error("Failed to reload selector profile, keeping previous one", "error", err)
+				continue
+			}
+			log.Info("Selector profile reloaded", "version", sel.Profile().Version)
+		}
+	}()
+
 	// Initialize browser
 	log.Info("Initializing browser...")
-	browserCtx, browserCancel := browser.Initialize(ctx, cfg)
-	if browserCtx == nil {
-		log.Fatal("Failed to initialize browser - please check Chrome installation")
+	session, err := browser.New(browserRoot, cfg, sel.Profile().UserAgent)
+	if err != nil {
+		log.Fatal("Failed to initialize browser", "error", err, "hint", cli.BrowserHint(err))
 	}
-	defer browserCancel()
+	browserCtx := session.Context()
 
-	log.Info("Browser initialized successfully")
+	// Relaunch the browser if it crashes or disconnects
+	sup := browser.NewSupervisor(browserRoot, cfg, session)
+	defer sup.Close()
+
+	log.Info("Browser initialized successfully", "version", session.Version().Product)
+
+	// Record console/network activity for failure evidence
+	var ev *evidence.Collector
+	if cfg.Evidence.Enabled {
+		ev = evidence.NewCollector(cfg.Evidence.Dir, cfg.Evidence.MaxEntries, cfg.Evidence.LogLines)
+		ev.Attach(browserCtx)
+	}
 
 	// Initialize authentication
 	log.Info("Authenticating with Shopee...")
-	authManager := auth.NewManager(browserCtx, cfg)
-	if err := authManager.Login(); err != nil {
+	authManager, err := auth.NewManager(browserCtx, cfg, sel)
+	if err != nil {
+		log.Fatal("Failed to open session store", "error", err)
+	}
+	codes, err := auth.NewCodeSource(cfg.Auth.Verification)
+	if err != nil {
+		log.Fatal("Invalid verification config", "error", err)
+	}
+	authManager.SetCodeSource(codes)
+	if err := authManager.Login(ctx); err != nil {
+		if dir, captureErr := ev.Capture(browserCtx, evidence.Info{Reason: "login-failure"}, err); dir != "" {
+			log.Info("Login failure evidence saved", "dir", dir, "capture_error", captureErr)
+		}
 		log.Fatal("Authentication failed", "error", err)
 	}
-	log.Info("Authentication successful!")
+	log.Info("Authentication successful!", "user", authManager.User().Username, "user_id", authManager.User().UserID, "source", authManager.User().Source)
+
+	// Warn ahead of session expiry so there is time to log in before a stream
+	notifier := notify.New(cfg.Monitoring.Notifications)
+	tasks.Go("session expiry watch", func() {
+		authManager.WatchExpiry(ctx, cfg.Auth.GetExpiryWarning(), func(exp auth.Expiry) {
+			log.Warn("Login session expires soon, log in again before the next stream", "expires", exp.At.Format(time.RFC1123), "in", time.Until(exp.At).Round(time.Minute), "cookie", exp.Cookie)
+			msg := fmt.Sprintf("%s expires %s (in %s)", exp.Cookie, exp.At.Format(time.RFC1123), time.Until(exp.At).Round(time.Minute))
+			if err := notifier.Send(ctx, notify.Warning, "Session expiring", msg); err != nil {
+				log.Warn("Failed to send notification", "error", err)
+			}
+		})
+	})
+
+	// A relaunched browser starts without our session and event listeners
+	sup.OnRestart(func(ctx context.Context) error {
+		ev.Attach(ctx)
+		return authManager.RestoreSession(ctx)
+	})
+	tasks.Go("browser supervisor", func() {
+		if err := sup.Run(ctx); err != nil && ctx.Err() == nil {
+			log.Error("Browser recovery gave up", "error", err)
+		}
+	})
 
 	// Initialize purchase executor
-	purchaseExec := purchase.NewExecutor(browserCtx, cfg)
+	purchaseExec := purchase.NewExecutor(cfg, sel, ev)
+
+	// Re-login in the background when the session is lost mid-stream
+	sessions := auth.NewSessionSupervisor(authManager, cfg.Auth.GetValidateInterval(), purchaseExec, notifier)
+	tasks.Go("session supervisor", func() { sessions.Run(ctx) })
+
+	// Remind before items added to the cart lose their reservation
+	reservations, err := cart.NewTracker(cfg.Cart.ReservationsFile, cfg.Cart.GetReminders(), notifier)
+	if err != nil {
+		log.Fatal("Failed to load cart reservations", "error", err)
+	}
+	tasks.Go("reservation reminders", func() { reservations.Run(ctx) })
+
+	// Automatic checkout exists only when explicitly enabled
+	co, err := checkout.New(cfg, sel, ev, notifier, reservations)
+	if err != nil {
+		log.Fatal("Failed to set up checkout", "error", err)
+	}
+	if co != nil {
+		log.Warn("Automatic checkout is ENABLED", "budget", cfg.Checkout.Budget, "period_hours", cfg.Checkout.BudgetPeriod, "max_order", cfg.Checkout.MaxOrder, "confirm", cfg.Checkout.Confirm.Sources)
+	}
 
 	// Initialize livestream monitor
 	log.Info("Starting livestream monitor...")
-	monitor := livestream.NewMonitor(browserCtx, cfg, purchaseExec)
+	// Streams firing at once buy in priority order within the purchase limits
+	dispatcher := purchase.NewDispatcher(cfg, purchaseExec)
+	monitor := livestream.NewMonitor(sup, sessions, cfg, sel, dispatcher, ev, reservations, co)
+
+	// The dashboard takes over the terminal from here on
+	stopDashboard := func() {}
+	if tui {
+		stopDashboard = startDashboard(cfg, monitor, authManager, sessions, dispatcher, co, reservations, func() {
+			select {
+			case sigChan <- os.Interrupt:
+			default:
+			}
+		})
+	}
 
 	// Start monitoring in a goroutine
-	go func() {
-		if err := monitor.Start(ctx); err != nil {
+	tasks.Go("monitor", func() {
+		if err := monitor.Start(ctx, tasks); err != nil && ctx.Err() == nil {
 			log.Error("Monitor stopped with error", "error", err)
 		}
-	}()
+	})
 
 	log.Info("Bot is now running! Monitoring livestreams...")
 	log.Info("Press Ctrl+C to stop")
 
 	// Wait for shutdown signal
 	<-sigChan
-	log.Info("Shutdown signal received, cleaning up...")
+	stopDashboard()
+	log.Info("Shutdown signal received, stopping...", "timeout", cfg.App.GetShutdownTimeout())
+	go func() {
+		<-sigChan
+		log.Warn("Second signal received, exiting immediately")
+		os.Exit(1)
+	}()
 
-	// Cancel context and wait for cleanup
+	// Stop streams (closing their tabs) and background work, then wait
 	cancel()
-	time.Sleep(2 * time.Second)
+	running := tasks.Wait(cfg.App.GetShutdownTimeout())
+	if len(running) > 0 {
+		log.Warn("Shutdown timed out, still running", "tasks", strings.Join(running, ", "))
+	}
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer shutdownCancel()
+	flush(shutdownCtx, log, authManager, reservations, notifier, running)
+
+	sup.Close()
+	closeBrowser()
 
 	log.Info("Bot stopped. Goodbye!")
 }
 
+// startDashboard shows the dashboard of `bot run --tui` until the returned
+// func is called. quit is called when the operator presses q.
+func startDashboard(cfg *config.Config, monitor *livestream.Monitor, authManager *auth.Manager, sessions *auth.SessionSupervisor, dispatcher *purchase.Dispatcher, co *checkout.Checkout, reservations *cart.Tracker, quit func()) (stop func()) {
+	dash := console.NewDashboard(fmt.Sprintf("%s %s (%s)", appName, appVersion, cfg.Shopee.Region), monitor, quit)
+
+	dash.AddInfo("Session", func() string {
+		select {
+		case <-sessions.Ready():
+			return "logged in as " + authManager.User().Username
+		default:
+			return "restoring login..."
+		}
+	})
+	dash.AddInfo("Purchases", func() string {
+		if reason := dispatcher.Paused(); reason != "" {
+			return "paused: " + reason
+		}
+		return "running"
+	})
+	dash.AddInfo("Added to cart", func() string {
+		return dispatcher.Spent().String()
+	})
+	dash.AddInfo("Budget left", func() string {
+		if left, ok := dispatcher.Left(); ok {
+			return left.String()
+		}
+		return "no limit"
+	})
+	if co != nil {
+		dash.AddInfo("Checkout budget left", func() string {
+			return co.Left().String()
+		})
+	}
+	dash.AddSection("Reserved in cart", func() []string {
+		var lines []string
+		for _, r := range reservations.List() {
+			lines = append(lines, fmt.Sprintf("%s  until %s (%s left)", r.Name, r.Until.Format("15:04:05"), time.Until(r.Until).Round(time.Second)))
+		}
+		return lines
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	console.SetOutput(dash)
+	go func() {
+		defer close(done)
+		dash.Run(ctx)
+	}()
+
+	return func() {
+		cancel()
+		<-done
+		console.SetOutput(console.Plain(os.Stdout))
+	}
+}
+
+// flush saves what the bot holds in memory and tells the operator it
+// stopped, listing any work that was cut off
+func flush(ctx context.Context, log *logger.Logger, authManager *auth.Manager, reservations *cart.Tracker, notifier *notify.Notifier, running []string) {
+	if err := reservations.Flush(); err != nil {
+		log.Warn("Failed to save cart reservations", "error", err)
+	}
+	if authManager.IsLoggedIn() {
+		if err := authManager.SaveSession(ctx); err != nil {
+			log.Warn("Failed to save session", "error", err)
+		}
+	}
+
+	msg := "shut down cleanly"
+	if len(running) > 0 {
+		msg = "shut down with work still running: " + strings.Join(running, ", ")
+	}
+	if err := notifier.Send(ctx, notify.Info, "Bot stopped", msg); err != nil {
+		log.Warn("Failed to send notification", "error", err)
+	}
+	if err := notifier.Flush(ctx); err != nil {
+		log.Warn("Failed to deliver notifications", "error", err)
+	}
+}
+
 func printBanner() {
 	banner := `
 ╔═══════════════════════════════════════════════════════════╗
@@ -7,7 +7,10 @@ require (
 	github.com/chromedp/chromedp v0.9.3
 	github.com/joho/godotenv v1.5.1
 	github.com/spf13/viper v1.18.2
+	golang.org/x/crypto v0.17.0
 	golang.org/x/sync v0.5.0
+	golang.org/x/sys v0.15.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -32,8 +35,6 @@ require (
 	go.uber.org/atomic v1.9.0 // indirect
 	go.uber.org/multierr v1.9.0 // indirect
 	golang.org/x/exp v0.0.0-20230905200255-921286631fa9 // indirect
-	golang.org/x/sys v0.15.0 // indirect
 	golang.org/x/text v0.14.0 // indirect
 	gopkg.in/ini.v1 v1.67.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -2,77 +2,107 @@ package auth
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
+	"sync"
 	"time"
 
 	"github.com/LLionNg/shopee-livestream-bot/internal/browser"
 	"github.com/LLionNg/shopee-livestream-bot/internal/config"
+	"github.com/LLionNg/shopee-livestream-bot/internal/console"
+	"github.com/LLionNg/shopee-livestream-bot/internal/selectors"
 	"github.com/chromedp/cdproto/network"
 	"github.com/chromedp/chromedp"
 )
 
 // Manager handles authentication and session management
 type Manager struct {
-	ctx         context.Context
-	cfg         *config.Config
-	sessionFile string
-	cookies     []*network.Cookie
-	isLoggedIn  bool
+	// mu keeps validation and re-login from racing a browser restart
+	mu    sync.Mutex
+	cfg   *config.Config
+	sel   *selectors.Store
+	store *SessionStore
+	codes CodeSource
+
+	// state guards the fields below, which the monitors read while a login
+	// holds mu
+	state      sync.RWMutex
+	tab        context.Context
+	cookies    []*network.Cookie
+	isLoggedIn bool
+	user       LoginState
 }
 
-// NewManager creates a new authentication manager
-func NewManager(ctx context.Context, cfg *config.Config) *Manager {
-	return &Manager{
-		ctx:         ctx,
-		cfg:         cfg,
-		sessionFile: "data/cookies/session.json",
-		isLoggedIn:  false,
+// NewManager creates a new authentication manager working in the browser
+// tab tab. Its methods run there, cancelled when the ctx passed to them is.
+func NewManager(tab context.Context, cfg *config.Config, sel *selectors.Store) (*Manager, error) {
+	store, err := OpenSessionStore(&cfg.Auth)
+	if err != nil {
+		return nil, err
 	}
+	return &Manager{
+		tab:        tab,
+		cfg:        cfg,
+		sel:        sel,
+		store:      store,
+		isLoggedIn: false,
+	}, nil
 }
 
 // Login performs login to Shopee
-func (m *Manager) Login() error {
+func (m *Manager) Login(ctx context.Context) error {
+	ctx, cancel := m.bind(ctx)
+	defer cancel()
+
 	// Try to load existing session first
-	if m.LoadSession() {
-		fmt.Println("📂 Found existing session, validating...")
-		if m.ValidateSession() {
-			fmt.Println("✅ Session is valid! Logged in successfully.")
-			m.isLoggedIn = true
+	err := m.LoadSession(ctx)
+	switch {
+	case err == nil:
+		console.Println("📂 Found existing session, validating...")
+		if m.ValidateSession(ctx) {
+			console.Printf("✅ Session is valid! %s\n", m.User())
 			return nil
 		}
-		fmt.Println("⚠️  Session expired, need to login again")
+		console.Println("⚠️  Session expired, need to login again")
+	case errors.Is(err, ErrSessionExpired):
+		console.Printf("⚠️  %v, need to login again\n", err)
+	case errors.Is(err, ErrSessionTampered):
+		return fmt.Errorf("%w (delete it to log in again, or check %s)", err, KeyEnv)
+	case !errors.Is(err, ErrNoSession):
+		return err
 	}
 
 	// Check if we have credentials for automatic login
-	fmt.Printf("🔐 Checking credentials - Username: '%s', Password: '%s'\n", m.cfg.Shopee.Credentials.Username, "***")
+	console.Printf("🔐 Checking credentials - Username: '%s', Password: '%s'\n", m.cfg.Shopee.Credentials.Username, "***")
 	if m.cfg.Shopee.Credentials.Username == "" || m.cfg.Shopee.Credentials.Password == "" {
-		fmt.Println("📝 No credentials provided - using MANUAL login mode")
-		fmt.Println("   You can login with any method: Facebook, Google, Username/Password, etc.")
-		return m.ManualLogin()
+		console.Println("📝 No credentials provided - using MANUAL login mode")
+		console.Println("   You can login with any method: Facebook, Google, Username/Password, etc.")
+		return m.ManualLogin(ctx)
 	}
 
 	// Perform automatic login with credentials
-	fmt.Println("🔑 Credentials found - using AUTOMATIC login mode")
-	return m.PerformLogin()
+	console.Println("🔑 Credentials found - using AUTOMATIC login mode")
+	return m.PerformLogin(ctx)
 }
 
 // ManualLogin guides user to login manually (supports any method including OAuth)
-func (m *Manager) ManualLogin() error {
+func (m *Manager) ManualLogin(ctx context.Context) error {
+	ctx, cancel := m.bind(ctx)
+	defer cancel()
+
 	// Navigate to Shopee login page
-	loginURL := m.cfg.Shopee.BaseURL + "/buyer/login"
+	loginURL := m.cfg.Shopee.LoginURL()
 
-	fmt.Printf("🔄 Navigating to login page: %s\n", loginURL)
+	console.Printf("🔄 Navigating to login page: %s\n", loginURL)
 
-	if err := browser.NavigateWithRetry(m.ctx, loginURL, 3); err != nil {
+	if err := browser.NavigateWithRetry(ctx, loginURL, 3); err != nil {
 		return fmt.Errorf("failed to navigate to login page: %w", err)
 	}
 
-	fmt.Println("🌐 Browser opened to Shopee login page")
-	fmt.Println("👉 Please login manually using any method (Username/Password, Facebook, Google, etc.)")
-	fmt.Println("⏳ Waiting for you to complete login...")
-	fmt.Println("   (The bot will automatically detect when you're logged in)")
+	console.Println("🌐 Browser opened to Shopee login page")
+	console.Println("👉 Please login manually using any method (Username/Password, Facebook, Google, etc.)")
+	console.Println("⏳ Waiting for you to complete login...")
+	console.Println("   (The bot will automatically detect when you're logged in)")
 
 	// Poll every 2 seconds to check if user has logged in
 	maxWaitTime := 5 * time.Minute
@@ -85,123 +115,148 @@ func (m *Manager) ManualLogin() error {
 			return fmt.Errorf("login timeout - please try again")
 		}
 
-		time.Sleep(checkInterval)
+		if err := browser.Sleep(ctx, checkInterval); err != nil {
+			return err
+		}
 
 		// Check current URL
 		var currentURL string
-		if err := chromedp.Run(m.ctx, chromedp.Location(&currentURL)); err != nil {
-			fmt.Printf("⚠️  Error getting URL: %v\n", err)
+		if err := chromedp.Run(ctx, chromedp.Location(&currentURL)); err != nil {
+			console.Printf("⚠️  Error getting URL: %v\n", err)
 			continue
 		}
 
-		fmt.Printf("🔍 Current URL: %s\n", currentURL)
+		console.Printf("🔍 Current URL: %s\n", currentURL)
 
 		// If no longer on login page, check if actually logged in
-		if !contains(currentURL, "/buyer/login") {
-			fmt.Println("📍 Not on login page anymore, checking if logged in...")
-
-			// Try multiple methods to detect login
-			var userExists bool
-
-			// Method 1: Check for common user menu elements
-			err := chromedp.Run(m.ctx,
-				chromedp.Evaluate(`
-					!!document.querySelector('[data-testid="account-menu"]') ||
-					!!document.querySelector('.navbar__username') ||
-					!!document.querySelector('a[href*="/user/account"]') ||
-					!!document.querySelector('.shopee-avatar') ||
-					!!document.cookie.includes('SPC_')
-				`, &userExists),
-			)
-
-			if err == nil && userExists {
-				fmt.Println("✅ Login detected! Saving session...")
-				m.isLoggedIn = true
-				return m.SaveSession()
+		if !contains(currentURL, m.loginPath()) {
+			console.Println("📍 Not on login page anymore, checking if logged in...")
+
+			if state := m.DetectLogin(ctx); state.LoggedIn {
+				console.Printf("✅ Login detected, %s! Saving session...\n", state)
+				m.setUser(state)
+				return m.SaveSession(ctx)
 			}
 
-			fmt.Println("⏳ Login not confirmed yet, still checking...")
+			console.Println("⏳ Login not confirmed yet, still checking...")
 		}
 	}
 }
 
 // PerformLogin executes the login flow
-func (m *Manager) PerformLogin() error {
+func (m *Manager) PerformLogin(ctx context.Context) error {
+	ctx, cancel := m.bind(ctx)
+	defer cancel()
+
 	// Navigate to Shopee login page
-	loginURL := m.cfg.Shopee.BaseURL + "/buyer/login"
+	loginURL := m.cfg.Shopee.LoginURL()
 
-	if err := browser.NavigateWithRetry(m.ctx, loginURL, 3); err != nil {
+	if err := browser.NavigateWithRetry(ctx, loginURL, 3); err != nil {
 		return fmt.Errorf("failed to navigate to login page: %w", err)
 	}
 
 	// Wait for page to load
-	time.Sleep(2 * time.Second)
+	if err := browser.Sleep(ctx, 2*time.Second); err != nil {
+		return err
+	}
 
 	// Check if already logged in (redirect to homepage)
 	var currentURL string
-	if err := chromedp.Run(m.ctx, chromedp.Location(&currentURL)); err != nil {
+	if err := chromedp.Run(ctx, chromedp.Location(&currentURL)); err != nil {
 		return err
 	}
 
-	if currentURL != loginURL && !contains(currentURL, "/buyer/login") {
+	if currentURL != loginURL && !contains(currentURL, m.loginPath()) {
 		// Already logged in
-		return m.SaveSession()
+		m.setUser(m.DetectLogin(ctx))
+		return m.SaveSession(ctx)
 	}
 
 	// Fill in login form
-	// Note: Shopee may require phone/email + password or phone + OTP
-	// This is a simplified version - real implementation may need SMS OTP handling
+	// Note: Shopee may follow up with an OTP or email link, see handleVerification
 
 	// Wait for login form
-	if err := browser.WaitForElement(m.ctx, "input[type='text']", 10*time.Second); err != nil {
+	usernameSelector, err := m.waitFor(ctx, selectors.LoginUsername, 10*time.Second)
+	if err != nil {
 		return fmt.Errorf("login form not found: %w", err)
 	}
 
+	passwordSelector, err := m.sel.Find(ctx, selectors.LoginPassword)
+	if err != nil {
+		return fmt.Errorf("password field not found: %w", err)
+	}
+	submitSelector, err := m.sel.Find(ctx, selectors.LoginSubmit)
+	if err != nil {
+		return fmt.Errorf("login button not found: %w", err)
+	}
+
 	// Method 1: Username/Email + Password
 	if m.cfg.Shopee.Credentials.Username != "" && m.cfg.Shopee.Credentials.Password != "" {
 		// Enter username/email
-		if err := browser.Type(m.ctx, "input[type='text']", m.cfg.Shopee.Credentials.Username); err != nil {
+		if err := browser.Type(ctx, usernameSelector, m.cfg.Shopee.Credentials.Username); err != nil {
 			return fmt.Errorf("failed to enter username: %w", err)
 		}
 
-		time.Sleep(500 * time.Millisecond)
+		if err := browser.Sleep(ctx, 500*time.Millisecond); err != nil {
+			return err
+		}
 
 		// Enter password
-		if err := browser.Type(m.ctx, "input[type='password']", m.cfg.Shopee.Credentials.Password); err != nil {
+		if err := browser.Type(ctx, passwordSelector, m.cfg.Shopee.Credentials.Password); err != nil {
 			return fmt.Errorf("failed to enter password: %w", err)
 		}
 
-		time.Sleep(500 * time.Millisecond)
+		if err := browser.Sleep(ctx, 500*time.Millisecond); err != nil {
+			return err
+		}
 
 		// Click login button
-		if err := browser.Click(m.ctx, "button[type='submit']"); err != nil {
+		if err := browser.Click(ctx, submitSelector); err != nil {
 			return fmt.Errorf("failed to click login button: %w", err)
 		}
 
 		// Wait for login to complete (check for redirect or success indicator)
-		time.Sleep(5 * time.Second)
+		if err := browser.Sleep(ctx, 5*time.Second); err != nil {
+			return err
+		}
+
+		// Answer an OTP or email verification step if Shopee asks for one
+		if err := m.handleVerification(ctx); err != nil {
+			return fmt.Errorf("login verification failed: %w", err)
+		}
 
 		// Check if login was successful
-		if err := chromedp.Run(m.ctx, chromedp.Location(&currentURL)); err != nil {
+		if err := chromedp.Run(ctx, chromedp.Location(&currentURL)); err != nil {
 			return err
 		}
 
-		if contains(currentURL, "/buyer/login") {
+		if contains(currentURL, m.loginPath()) {
 			return fmt.Errorf("login failed - still on login page")
 		}
 
+		// Leaving the login page isn't enough, the account has to answer
+		state := m.DetectLogin(ctx)
+		if !state.LoggedIn {
+			return fmt.Errorf("login failed - %s after submitting the form", state)
+		}
+		m.setUser(state)
+		console.Printf("✅ %s\n", state)
+
 		// Save session after successful login
-		return m.SaveSession()
+		return m.SaveSession(ctx)
 	}
 
 	return fmt.Errorf("no valid login credentials provided")
 }
 
 // SaveSession saves current session cookies to file
-func (m *Manager) SaveSession() error {
+func (m *Manager) SaveSession(ctx context.Context) error {
+	ctx, cancel := m.bind(ctx)
+	defer cancel()
+
 	// Get all cookies
 	var cookies []*network.Cookie
-	if err := chromedp.Run(m.ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+	if err := chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
 		c, err := network.GetCookies().Do(ctx)
 		if err != nil {
 			return err
@@ -212,126 +267,258 @@ func (m *Manager) SaveSession() error {
 		return fmt.Errorf("failed to get cookies: %w", err)
 	}
 
-	m.cookies = cookies
-
-	// Create directory if not exists
-	os.MkdirAll("data/cookies", 0755)
-
-	// Save to file
-	data, err := json.MarshalIndent(cookies, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal cookies: %w", err)
-	}
-
-	if err := os.WriteFile(m.sessionFile, data, 0600); err != nil {
-		return fmt.Errorf("failed to write session file: %w", err)
+	// Save encrypted to file
+	if err := m.store.Save(cookies); err != nil {
+		return err
 	}
 
+	m.state.Lock()
+	m.cookies = cookies
 	m.isLoggedIn = true
+	m.state.Unlock()
 	return nil
 }
 
-// LoadSession loads session cookies from file
-func (m *Manager) LoadSession() bool {
-	// Check if session file exists
-	if _, err := os.Stat(m.sessionFile); os.IsNotExist(err) {
-		return false
-	}
+// LoadSession loads session cookies from the encrypted session file into
+// the browser. It returns ErrNoSession when nothing was saved,
+// ErrSessionTampered when the file fails authentication and
+// ErrSessionExpired when the login cookies have run out.
+func (m *Manager) LoadSession(ctx context.Context) error {
+	ctx, cancel := m.bind(ctx)
+	defer cancel()
 
-	// Read session file
-	data, err := os.ReadFile(m.sessionFile)
+	cookies, err := m.store.Load()
 	if err != nil {
-		return false
+		return err
 	}
 
-	// Unmarshal cookies
-	var cookies []*network.Cookie
-	if err := json.Unmarshal(data, &cookies); err != nil {
-		return false
+	// Check the expiry before spending a page load on validation
+	exp := SessionExpiry(cookies)
+	if exp.Expired(time.Now()) {
+		return fmt.Errorf("%w (%s expired %s)", ErrSessionExpired, exp.Cookie, exp.At.Format(time.RFC1123))
+	}
+	if exp.Known() {
+		console.Printf("📅 Saved session expires %s (in %s)\n", exp.At.Format(time.RFC1123), time.Until(exp.At).Round(time.Minute))
 	}
 
 	// Set cookies in browser
-	if err := chromedp.Run(m.ctx, chromedp.ActionFunc(func(ctx context.Context) error {
-		for _, cookie := range cookies {
-			if err := network.SetCookie(cookie.Name, cookie.Value).
-				WithDomain(cookie.Domain).
-				WithPath(cookie.Path).
-				WithHTTPOnly(cookie.HTTPOnly).
-				WithSecure(cookie.Secure).
-				Do(ctx); err != nil {
-				return err
+	if err := setCookies(ctx, cookies); err != nil {
+		return fmt.Errorf("failed to restore cookies: %w", err)
+	}
+
+	m.state.Lock()
+	m.cookies = cookies
+	m.state.Unlock()
+	return nil
+}
+
+// Store returns the encrypted session store
+func (m *Manager) Store() *SessionStore {
+	return m.store
+}
+
+// Expiry returns when the current session's login cookies expire
+func (m *Manager) Expiry() Expiry {
+	m.state.RLock()
+	defer m.state.RUnlock()
+	return SessionExpiry(m.cookies)
+}
+
+// WatchExpiry calls warn once the session is within before of expiring,
+// until ctx is done. It follows renewed cookies after a new login.
+func (m *Manager) WatchExpiry(ctx context.Context, before time.Duration, warn func(Expiry)) {
+	var warned time.Time
+	for {
+		wait := time.Hour
+		if exp := m.Expiry(); exp.Known() && !exp.At.Equal(warned) {
+			if until := time.Until(exp.At) - before; until <= 0 {
+				warn(exp)
+				warned = exp.At
+			} else if until < wait {
+				wait = until
 			}
 		}
-		return nil
-	})); err != nil {
-		return false
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
 	}
+}
 
-	m.cookies = cookies
-	return true
+// RestoreSession switches the manager to a relaunched browser and puts the
+// session cookies from the last login back into it
+func (m *Manager) RestoreSession(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.state.Lock()
+	m.tab = ctx
+	cookies := m.cookies
+	m.state.Unlock()
+
+	if len(cookies) == 0 {
+		return nil
+	}
+	if err := setCookies(ctx, cookies); err != nil {
+		return fmt.Errorf("failed to restore session cookies: %w", err)
+	}
+	return nil
 }
 
 // ValidateSession checks if the current session is still valid
-func (m *Manager) ValidateSession() bool {
+func (m *Manager) ValidateSession(ctx context.Context) bool {
+	ctx, cancel := m.bind(ctx)
+	defer cancel()
+
 	// Navigate to a page that requires authentication
-	if err := browser.NavigateWithRetry(m.ctx, m.cfg.Shopee.BaseURL, 3); err != nil {
+	if err := browser.NavigateWithRetry(ctx, m.cfg.Shopee.BaseURL, 3); err != nil {
 		return false
 	}
 
-	time.Sleep(2 * time.Second)
+	if browser.Sleep(ctx, 2*time.Second) != nil {
+		return false
+	}
 
 	// Check current URL
 	var currentURL string
-	if err := chromedp.Run(m.ctx, chromedp.Location(&currentURL)); err != nil {
+	if err := chromedp.Run(ctx, chromedp.Location(&currentURL)); err != nil {
 		return false
 	}
 
 	// If redirected to login page, session is invalid
-	if contains(currentURL, "/buyer/login") {
+	if contains(currentURL, m.loginPath()) {
 		return false
 	}
 
-	// Try to find user-specific elements (e.g., profile icon)
-	// This is a simplified check
-	var userExists bool
-	err := chromedp.Run(m.ctx,
-		chromedp.Evaluate(`!!document.querySelector('[data-testid="account-menu"]')`, &userExists),
-	)
+	state := m.DetectLogin(ctx)
+	m.setUser(state)
+	return state.LoggedIn
+}
 
-	return err == nil && userExists
+// User returns who the last login check found logged in
+func (m *Manager) User() LoginState {
+	m.state.RLock()
+	defer m.state.RUnlock()
+	return m.user
 }
 
 // IsLoggedIn returns whether user is currently logged in
 func (m *Manager) IsLoggedIn() bool {
+	m.state.RLock()
+	defer m.state.RUnlock()
 	return m.isLoggedIn
 }
 
+// setUser records the result of a login check
+func (m *Manager) setUser(user LoginState) {
+	m.state.Lock()
+	defer m.state.Unlock()
+	m.user = user
+	m.isLoggedIn = user.LoggedIn
+}
+
+// setLoggedIn marks the session logged in or out
+func (m *Manager) setLoggedIn(loggedIn bool) {
+	m.state.Lock()
+	defer m.state.Unlock()
+	m.isLoggedIn = loggedIn
+}
+
 // Logout performs logout
-func (m *Manager) Logout() error {
+func (m *Manager) Logout(ctx context.Context) error {
+	ctx, cancel := m.bind(ctx)
+	defer cancel()
+
 	// Clear cookies
-	if err := chromedp.Run(m.ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+	if err := chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
 		return network.ClearBrowserCookies().Do(ctx)
 	})); err != nil {
 		return err
 	}
 
 	// Delete session file
-	os.Remove(m.sessionFile)
+	m.store.Remove()
 
+	m.state.Lock()
 	m.isLoggedIn = false
 	m.cookies = nil
+	m.state.Unlock()
 
 	return nil
 }
 
 // RefreshSession refreshes the current session
-func (m *Manager) RefreshSession() error {
-	if !m.ValidateSession() {
-		return m.PerformLogin()
+func (m *Manager) RefreshSession(ctx context.Context) error {
+	if !m.ValidateSession(ctx) {
+		return m.PerformLogin(ctx)
 	}
 	return nil
 }
 
+// checkSession validates the session unless the browser is being replaced
+func (m *Manager) checkSession(ctx context.Context) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// A browser restart is not a logout; RestoreSession brings the cookies back
+	m.state.RLock()
+	tab := m.tab
+	m.state.RUnlock()
+	if tab.Err() != nil {
+		return true
+	}
+	return m.ValidateSession(ctx)
+}
+
+// relogin logs in again with the configured credentials, or waits for a
+// manual login after calling manual. The new cookies are saved.
+func (m *Manager) relogin(ctx context.Context, manual func()) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.setLoggedIn(false)
+	if m.ValidateSession(ctx) {
+		// Another tab or the operator already fixed it
+		return m.SaveSession(ctx)
+	}
+
+	if m.cfg.Shopee.Credentials.Username == "" || m.cfg.Shopee.Credentials.Password == "" {
+		manual()
+		return m.ManualLogin(ctx)
+	}
+	return m.PerformLogin(ctx)
+}
+
+// bind returns a context for the manager's tab that also ends with ctx
+func (m *Manager) bind(ctx context.Context) (context.Context, context.CancelFunc) {
+	m.state.RLock()
+	tab := m.tab
+	m.state.RUnlock()
+	return browser.Bind(ctx, tab)
+}
+
+// waitFor polls until an element is on the page and returns its selector,
+// or Find's error once timeout passes
+func (m *Manager) waitFor(ctx context.Context, name string, timeout time.Duration) (string, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		selector, err := m.sel.Find(ctx, name)
+		if err == nil || ctx.Err() != nil || time.Now().After(deadline) {
+			return selector, err
+		}
+		if err := browser.Sleep(ctx, 500*time.Millisecond); err != nil {
+			return "", err
+		}
+	}
+}
+
+// loginPath returns the region's login page path
+func (m *Manager) loginPath() string {
+	return m.cfg.Shopee.GetRegion().LoginPath
+}
+
 // helper function
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && s[:len(substr)] == substr ||
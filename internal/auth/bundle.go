@@ -0,0 +1,264 @@
+package auth
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/LLionNg/shopee-livestream-bot/internal/browser"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+	"golang.org/x/crypto/scrypt"
+)
+
+// BundlePassphraseEnv holds the passphrase for encrypted bundles, so export
+// and import can run without a prompt
+const BundlePassphraseEnv = "SHOPEE_BUNDLE_PASSPHRASE"
+
+const (
+	// bundleVersion is bumped when the bundle layout changes
+	bundleVersion = 1
+	// bundleFormat marks an encrypted bundle file
+	bundleFormat = "shopee-session-bundle/scrypt-aes-256-gcm/v1"
+	// scrypt cost parameters for the bundle key, fixed by bundleFormat
+	scryptN, scryptR, scryptP = 1 << 15, 8, 1
+)
+
+// ErrBundlePassphrase means an encrypted bundle could not be opened with
+// the passphrase given
+var ErrBundlePassphrase = errors.New("wrong passphrase or bundle was modified")
+
+// sealedBundle is an encrypted bundle file. The key is derived from a
+// passphrase with scrypt and the salt, so the bundle opens on any machine.
+type sealedBundle struct {
+	Format string `json:"format"`
+	Salt   string `json:"salt"`
+	Nonce  string `json:"nonce"`
+	Data   string `json:"data"`
+}
+
+// Bundle is a portable copy of a logged-in session: the cookies plus the
+// Shopee origin's web storage, e.g. to move a desktop login to a server
+type Bundle struct {
+	Version        int               `json:"version"`
+	ExportedAt     time.Time         `json:"exported_at"`
+	Origin         string            `json:"origin"`
+	UserAgent      string            `json:"user_agent"`
+	Cookies        []*network.Cookie `json:"cookies"`
+	LocalStorage   map[string]string `json:"local_storage"`
+	SessionStorage map[string]string `json:"session_storage"`
+}
+
+// storageJS reads both web storages of the current page
+const storageJS = `(() => {
+	const dump = s => Object.fromEntries(Array.from({length: s.length}, (_, i) => [s.key(i), s.getItem(s.key(i))]));
+	return {local: dump(localStorage), session: dump(sessionStorage), ua: navigator.userAgent};
+})()`
+
+// storageDump is what storageJS returns
+type storageDump struct {
+	Local   map[string]string `json:"local"`
+	Session map[string]string `json:"session"`
+	UA      string            `json:"ua"`
+}
+
+// ExportBundle captures the current session from the browser. The saved
+// session must already be loaded (see LoadSession).
+func (m *Manager) ExportBundle(ctx context.Context) (*Bundle, error) {
+	ctx, cancel := m.bind(ctx)
+	defer cancel()
+
+	origin := m.cfg.Shopee.BaseURL
+	if err := browser.NavigateWithRetry(ctx, origin, 3); err != nil {
+		return nil, err
+	}
+
+	var dump storageDump
+	var cookies []*network.Cookie
+	err := chromedp.Run(ctx,
+		chromedp.Evaluate(storageJS, &dump),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			var err error
+			cookies, err = network.GetCookies().WithUrls([]string{origin, m.cfg.Shopee.APIURL}).Do(ctx)
+			return err
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session from browser: %w", err)
+	}
+
+	return &Bundle{
+		Version:        bundleVersion,
+		ExportedAt:     time.Now(),
+		Origin:         origin,
+		UserAgent:      dump.UA,
+		Cookies:        cookies,
+		LocalStorage:   dump.Local,
+		SessionStorage: dump.Session,
+	}, nil
+}
+
+// ImportBundle injects a bundle's cookies and web storage into the browser
+// and reloads the origin so the page sees them. It returns the browser's
+// own user agent so callers can compare it with the exporting machine's.
+func (m *Manager) ImportBundle(ctx context.Context, b *Bundle) (string, error) {
+	ctx, cancel := m.bind(ctx)
+	defer cancel()
+
+	if b.Origin != m.cfg.Shopee.BaseURL {
+		return "", fmt.Errorf("bundle is for %s but shopee.base_url is %s", b.Origin, m.cfg.Shopee.BaseURL)
+	}
+
+	if err := setCookies(ctx, b.Cookies); err != nil {
+		return "", fmt.Errorf("failed to set cookies: %w", err)
+	}
+
+	// Web storage can only be written from a page on the origin
+	if err := browser.NavigateWithRetry(ctx, b.Origin, 3); err != nil {
+		return "", err
+	}
+	local, _ := json.Marshal(b.LocalStorage)
+	session, _ := json.Marshal(b.SessionStorage)
+	script := fmt.Sprintf(`(() => {
+		for (const [k, v] of Object.entries(%s)) localStorage.setItem(k, v);
+		for (const [k, v] of Object.entries(%s)) sessionStorage.setItem(k, v);
+		return navigator.userAgent;
+	})()`, local, session)
+
+	var userAgent string
+	if err := chromedp.Run(ctx, chromedp.Evaluate(script, &userAgent), chromedp.Reload()); err != nil {
+		return "", fmt.Errorf("failed to restore web storage: %w", err)
+	}
+
+	m.state.Lock()
+	m.cookies = b.Cookies
+	m.state.Unlock()
+	return userAgent, nil
+}
+
+// WriteBundle saves a bundle to path, encrypted with passphrase unless it
+// is empty
+func WriteBundle(path string, b *Bundle, passphrase string) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal bundle: %w", err)
+	}
+	if passphrase != "" {
+		if data, err = sealBundle(data, passphrase); err != nil {
+			return err
+		}
+	}
+	if err := writeFileAtomic(path, data); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	return nil
+}
+
+// ReadBundle loads a bundle from path. passphrase is only called for an
+// encrypted bundle.
+func ReadBundle(path string, passphrase func() (string, error)) (*Bundle, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bundle: %w", err)
+	}
+
+	var probe struct {
+		Format string `json:"format"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, fmt.Errorf("failed to parse bundle %s: %w", path, err)
+	}
+	switch {
+	case probe.Format == bundleFormat:
+		pass, err := passphrase()
+		if err != nil {
+			return nil, err
+		}
+		if data, err = openBundle(data, pass); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	case probe.Format != "":
+		return nil, fmt.Errorf("bundle %s is encrypted as %q, expected %q; export it again", path, probe.Format, bundleFormat)
+	}
+
+	var b Bundle
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("failed to parse bundle %s: %w", path, err)
+	}
+	if b.Version != bundleVersion {
+		return nil, fmt.Errorf("bundle %s has version %d, expected %d", path, b.Version, bundleVersion)
+	}
+	return &b, nil
+}
+
+// sealBundle encrypts a bundle with AES-256-GCM under a key derived from
+// passphrase and a fresh salt
+func sealBundle(plain []byte, passphrase string) ([]byte, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	aead, err := bundleCipher(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := sealedBundle{
+		Format: bundleFormat,
+		Salt:   base64.StdEncoding.EncodeToString(salt),
+		Nonce:  base64.StdEncoding.EncodeToString(nonce),
+		Data:   base64.StdEncoding.EncodeToString(aead.Seal(nil, nonce, plain, []byte(bundleFormat))),
+	}
+	return json.MarshalIndent(sealed, "", "  ")
+}
+
+// openBundle decrypts a sealedBundle document, returning
+// ErrBundlePassphrase when it fails authentication
+func openBundle(data []byte, passphrase string) ([]byte, error) {
+	var sealed sealedBundle
+	if err := json.Unmarshal(data, &sealed); err != nil || sealed.Format != bundleFormat {
+		return nil, ErrBundlePassphrase
+	}
+	salt, err1 := base64.StdEncoding.DecodeString(sealed.Salt)
+	nonce, err2 := base64.StdEncoding.DecodeString(sealed.Nonce)
+	ciphertext, err3 := base64.StdEncoding.DecodeString(sealed.Data)
+	if err1 != nil || err2 != nil || err3 != nil || len(salt) == 0 {
+		return nil, ErrBundlePassphrase
+	}
+	aead, err := bundleCipher(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != aead.NonceSize() {
+		return nil, ErrBundlePassphrase
+	}
+
+	plain, err := aead.Open(nil, nonce, ciphertext, []byte(bundleFormat))
+	if err != nil {
+		return nil, ErrBundlePassphrase
+	}
+	return plain, nil
+}
+
+// bundleCipher derives the bundle key from passphrase and salt
+func bundleCipher(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, 32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive bundle key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
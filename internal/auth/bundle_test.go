@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func testBundle() *Bundle {
+	return &Bundle{
+		Version:      bundleVersion,
+		ExportedAt:   time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		Origin:       "https://shopee.co.th",
+		Cookies:      testCookies,
+		LocalStorage: map[string]string{"token": "abc"},
+	}
+}
+
+func passphrase(p string) func() (string, error) {
+	return func() (string, error) { return p, nil }
+}
+
+func TestBundleRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.bundle")
+
+	// Plain bundles never ask for a passphrase
+	if err := WriteBundle(path, testBundle(), ""); err != nil {
+		t.Fatal(err)
+	}
+	asked := func() (string, error) { return "", errors.New("asked for a passphrase") }
+	if b, err := ReadBundle(path, asked); err != nil || b.LocalStorage["token"] != "abc" {
+		t.Fatalf("ReadBundle plain = %+v, %v", b, err)
+	}
+
+	if err := WriteBundle(path, testBundle(), "correct horse"); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if bytes.Contains(data, []byte("secret")) {
+		t.Errorf("encrypted bundle holds the cookies in plaintext:\n%s", data)
+	}
+	b, err := ReadBundle(path, passphrase("correct horse"))
+	if err != nil {
+		t.Fatalf("ReadBundle: %v", err)
+	}
+	if len(b.Cookies) != 2 || b.Cookies[1].Value != "secret" || b.Origin != "https://shopee.co.th" {
+		t.Errorf("ReadBundle = %+v, want the written bundle", b)
+	}
+}
+
+func TestBundleWrongPassphrase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.bundle")
+	if err := WriteBundle(path, testBundle(), "correct horse"); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	var sealed sealedBundle
+	if err := json.Unmarshal(data, &sealed); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ReadBundle(path, passphrase("battery staple")); !errors.Is(err, ErrBundlePassphrase) {
+		t.Errorf("wrong passphrase: got %v, want ErrBundlePassphrase", err)
+	}
+
+	// A new salt gives a new key, so the passphrase no longer fits
+	other := sealed
+	other.Salt = "AAAAAAAAAAAAAAAAAAAAAA=="
+	data, _ = json.Marshal(other)
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadBundle(path, passphrase("correct horse")); !errors.Is(err, ErrBundlePassphrase) {
+		t.Errorf("changed salt: got %v, want ErrBundlePassphrase", err)
+	}
+}
@@ -0,0 +1,227 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/LLionNg/shopee-livestream-bot/internal/config"
+	"github.com/LLionNg/shopee-livestream-bot/internal/console"
+)
+
+// Challenge kinds
+const (
+	ChallengeOTP       = "otp"
+	ChallengeEmailLink = "email_link"
+	ChallengeApproval  = "approval"
+)
+
+// Challenge is a question for the operator: a verification step Shopee put
+// between the login form and the account, or an action awaiting approval
+type Challenge struct {
+	Kind   string
+	Prompt string
+}
+
+// CodeSource asks the human operator to answer a challenge. For an OTP the
+// answer is the code; for an email link it is the link from the email, or
+// anything else once the link was opened on another device; for an approval
+// it is yes or no.
+type CodeSource interface {
+	Code(ctx context.Context, ch Challenge) (string, error)
+}
+
+// NewCodeSource builds the sources listed in the verification config. With
+// several sources the first answer wins.
+func NewCodeSource(cfg config.VerificationConfig) (CodeSource, error) {
+	var sources []CodeSource
+	for _, name := range cfg.Sources {
+		switch name {
+		case "terminal":
+			sources = append(sources, TerminalSource{})
+		case "telegram":
+			sources = append(sources, NewTelegramSource(cfg.Telegram.BotToken, cfg.Telegram.ChatID))
+		default:
+			return nil, fmt.Errorf("unknown verification source %q", name)
+		}
+	}
+
+	switch len(sources) {
+	case 0:
+		return nil, nil
+	case 1:
+		return sources[0], nil
+	}
+	return FirstOf(sources), nil
+}
+
+// FirstOf asks every source at once and returns the first answer
+type FirstOf []CodeSource
+
+// Code implements CodeSource
+func (f FirstOf) Code(ctx context.Context, ch Challenge) (string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type answer struct {
+		code string
+		err  error
+	}
+	answers := make(chan answer, len(f))
+	for _, src := range f {
+		src := src
+		go func() {
+			code, err := src.Code(ctx, ch)
+			answers <- answer{code, err}
+		}()
+	}
+
+	var lastErr error
+	for range f {
+		a := <-answers
+		if a.err == nil {
+			return a.code, nil
+		}
+		lastErr = a.err
+	}
+	return "", lastErr
+}
+
+// TerminalSource reads the answer from standard input
+type TerminalSource struct{}
+
+// Code implements CodeSource
+func (TerminalSource) Code(ctx context.Context, ch Challenge) (string, error) {
+	return console.Prompt(ctx, fmt.Sprintf("🔐 %s\n👉 ", ch.Prompt))
+}
+
+// TelegramSource sends the prompt to a Telegram chat and takes the next
+// message posted there as the answer. Questions are asked one at a time, so
+// each reply goes to the question before it.
+type TelegramSource struct {
+	token  string
+	chatID string
+	client *http.Client
+
+	turn   chan struct{} // held while a question waits for its reply
+	offset int64         // next update to read; guarded by turn
+}
+
+// NewTelegramSource creates a source using a bot token and chat ID
+func NewTelegramSource(token, chatID string) *TelegramSource {
+	return &TelegramSource{
+		token:  token,
+		chatID: chatID,
+		client: &http.Client{Timeout: 40 * time.Second},
+		turn:   make(chan struct{}, 1),
+	}
+}
+
+// telegramUpdate is the part of a Telegram update the bot reads
+type telegramUpdate struct {
+	UpdateID int64 `json:"update_id"`
+	Message  *struct {
+		Date int64  `json:"date"`
+		Text string `json:"text"`
+		Chat struct {
+			ID int64 `json:"id"`
+		} `json:"chat"`
+	} `json:"message"`
+}
+
+// Code implements CodeSource
+func (t *TelegramSource) Code(ctx context.Context, ch Challenge) (string, error) {
+	select {
+	case t.turn <- struct{}{}:
+		defer func() { <-t.turn }()
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+
+	sent := time.Now().Unix()
+	if err := t.call(ctx, "sendMessage", map[string]string{
+		"chat_id": t.chatID,
+		"text":    "🔐 Shopee bot: " + ch.Prompt,
+	}, nil); err != nil {
+		return "", err
+	}
+
+	for {
+		var updates []telegramUpdate
+		params := map[string]string{
+			"offset":  strconv.FormatInt(t.offset, 10),
+			"timeout": "30",
+		}
+		if err := t.call(ctx, "getUpdates", params, &updates); err != nil {
+			if ctx.Err() != nil {
+				return "", ctx.Err()
+			}
+			// Telegram hiccups are common; keep polling until the timeout
+			select {
+			case <-ctx.Done():
+				return "", ctx.Err()
+			case <-time.After(2 * time.Second):
+			}
+			continue
+		}
+
+		for _, u := range updates {
+			t.offset = u.UpdateID + 1
+			msg := u.Message
+			if msg == nil || strconv.FormatInt(msg.Chat.ID, 10) != t.chatID || msg.Date < sent {
+				continue
+			}
+			if text := strings.TrimSpace(msg.Text); text != "" {
+				return text, nil
+			}
+		}
+	}
+}
+
+// call invokes a Telegram Bot API method and decodes its result into out
+func (t *TelegramSource) call(ctx context.Context, method string, params map[string]string, out interface{}) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	endpoint := "https://api.telegram.org/bot" + url.PathEscape(t.token) + "/" + method
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("telegram %s: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("telegram %s: %w", method, err)
+	}
+
+	var result struct {
+		OK          bool            `json:"ok"`
+		Description string          `json:"description"`
+		Result      json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return fmt.Errorf("telegram %s: %s", method, resp.Status)
+	}
+	if !result.OK {
+		return fmt.Errorf("telegram %s: %s", method, result.Description)
+	}
+	if out != nil {
+		return json.Unmarshal(result.Result, out)
+	}
+	return nil
+}
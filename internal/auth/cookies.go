@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+// authCookies are the Shopee cookies that carry the login itself; the
+// session ends when the first of them expires
+var authCookies = []string{"SPC_EC", "SPC_ST", "SPC_U"}
+
+// Expiry describes when a saved session stops working
+type Expiry struct {
+	At     time.Time // zero when no auth cookie has an expiry date
+	Cookie string    // the auth cookie that expires first
+}
+
+// Known reports whether the session has an expiry date
+func (e Expiry) Known() bool {
+	return !e.At.IsZero()
+}
+
+// Expired reports whether the session has expired at now
+func (e Expiry) Expired(now time.Time) bool {
+	return e.Known() && !now.Before(e.At)
+}
+
+// SessionExpiry works out when the session in cookies effectively expires:
+// the earliest expiry of the auth cookies, or of any SPC_* cookie when none
+// of those are present. Browser-session cookies don't count.
+func SessionExpiry(cookies []*network.Cookie) Expiry {
+	if exp := earliestExpiry(cookies, isAuthCookie); exp.Known() {
+		return exp
+	}
+	return earliestExpiry(cookies, func(name string) bool {
+		return strings.HasPrefix(name, "SPC_")
+	})
+}
+
+// earliestExpiry returns the first expiry among persistent cookies whose
+// name matches
+func earliestExpiry(cookies []*network.Cookie, match func(name string) bool) Expiry {
+	var exp Expiry
+	for _, c := range cookies {
+		if !match(c.Name) || c.Session || c.Expires <= 0 {
+			continue
+		}
+		if at := cookieTime(c.Expires); !exp.Known() || at.Before(exp.At) {
+			exp = Expiry{At: at, Cookie: c.Name}
+		}
+	}
+	return exp
+}
+
+// isAuthCookie reports whether name is one of the login cookies
+func isAuthCookie(name string) bool {
+	for _, n := range authCookies {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// LiveCookies drops cookies that have expired at now
+func LiveCookies(cookies []*network.Cookie, now time.Time) []*network.Cookie {
+	var live []*network.Cookie
+	for _, c := range cookies {
+		if !c.Session && c.Expires > 0 && !now.Before(cookieTime(c.Expires)) {
+			continue
+		}
+		live = append(live, c)
+	}
+	return live
+}
+
+// setCookies installs cookies into the browser behind ctx with all their
+// attributes, skipping those that have already expired
+func setCookies(ctx context.Context, cookies []*network.Cookie) error {
+	return chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		for _, cookie := range LiveCookies(cookies, time.Now()) {
+			params := network.SetCookie(cookie.Name, cookie.Value).
+				WithDomain(cookie.Domain).
+				WithPath(cookie.Path).
+				WithHTTPOnly(cookie.HTTPOnly).
+				WithSecure(cookie.Secure).
+				WithSameSite(cookie.SameSite).
+				WithPriority(cookie.Priority).
+				WithPartitionKey(cookie.PartitionKey)
+			if !cookie.Session && cookie.Expires > 0 {
+				expires := cdp.TimeSinceEpoch(cookieTime(cookie.Expires))
+				params = params.WithExpires(&expires)
+			}
+			if err := params.Do(ctx); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+}
+
+// cookieTime converts a cookie's expiry (seconds since the epoch) to a time
+func cookieTime(expires float64) time.Time {
+	return time.Unix(0, int64(expires*float64(time.Second)))
+}
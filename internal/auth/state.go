@@ -0,0 +1,147 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/LLionNg/shopee-livestream-bot/internal/selectors"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+)
+
+// userCookie holds the logged-in user's ID; anonymous visitors get "-" or
+// no cookie at all, while other SPC_* cookies are set for everyone
+const userCookie = "SPC_U"
+
+// Sources a login state can be decided by
+const (
+	SourceAPI    = "account api"
+	SourceCookie = "cookie"
+	SourceDOM    = "page"
+)
+
+// LoginState is the result of DetectLogin
+type LoginState struct {
+	LoggedIn bool
+	UserID   int64
+	Username string
+	Source   string // what decided the state
+}
+
+// String describes the state for logs and status output
+func (s LoginState) String() string {
+	if !s.LoggedIn {
+		return "not logged in"
+	}
+	who := s.Username
+	if who == "" {
+		who = "unknown user"
+	}
+	if s.UserID != 0 {
+		who = fmt.Sprintf("%s (id %d)", who, s.UserID)
+	}
+	return fmt.Sprintf("logged in as %s via %s", who, s.Source)
+}
+
+// accountJS fetches the account endpoint from the page so the browser's
+// cookies are sent; it resolves to null when the endpoint is unreachable
+const accountJS = `fetch(%s, {credentials: 'include', headers: {'Accept': 'application/json'}})
+	.then(r => r.ok ? r.text() : null)
+	.catch(() => null)`
+
+// accountInfo is the part of the account endpoint's answer the bot reads.
+// Shopee wraps the user in "data"; stand-ins may return it at the top level.
+type accountInfo struct {
+	Error  int    `json:"error"`
+	UserID int64  `json:"userid"`
+	Name   string `json:"username"`
+	Data   *struct {
+		UserID int64  `json:"userid"`
+		Name   string `json:"username"`
+	} `json:"data"`
+}
+
+// DetectLogin decides whether the browser behind ctx is logged in. The
+// account endpoint is authoritative when it answers; otherwise the user
+// cookie decides and the page's account markers supply the username.
+// ctx should be on a Shopee page so the endpoint is same-origin.
+func (m *Manager) DetectLogin(ctx context.Context) LoginState {
+	if state, ok := m.accountState(ctx); ok {
+		return state
+	}
+
+	state := LoginState{Source: SourceCookie}
+	state.UserID = cookieUserID(ctx)
+	if state.UserID == 0 {
+		return state
+	}
+	state.LoggedIn = true
+
+	// The account menu usually shows the username
+	if name, err := m.sel.Text(ctx, selectors.AccountMenu); err == nil {
+		state.Username = strings.TrimSpace(name)
+		state.Source = SourceCookie + "+" + SourceDOM
+	} else if m.markersPresent(ctx) {
+		state.Source = SourceCookie + "+" + SourceDOM
+	}
+	return state
+}
+
+// accountState asks the account endpoint who is logged in. ok is false
+// when the endpoint could not be reached or gave an unreadable answer.
+func (m *Manager) accountState(ctx context.Context) (LoginState, bool) {
+	url, _ := json.Marshal(m.cfg.Auth.AccountURL(m.cfg.Shopee.APIURL))
+
+	var body *string
+	err := chromedp.Run(ctx, chromedp.Evaluate(fmt.Sprintf(accountJS, url), &body,
+		func(p *runtime.EvaluateParams) *runtime.EvaluateParams {
+			return p.WithAwaitPromise(true)
+		}))
+	if err != nil || body == nil {
+		return LoginState{}, false
+	}
+
+	var info accountInfo
+	if err := json.Unmarshal([]byte(*body), &info); err != nil {
+		return LoginState{}, false
+	}
+
+	state := LoginState{Source: SourceAPI, UserID: info.UserID, Username: info.Name}
+	if info.Data != nil {
+		state.UserID, state.Username = info.Data.UserID, info.Data.Name
+	}
+	state.LoggedIn = info.Error == 0 && state.UserID != 0
+	return state, true
+}
+
+// markersPresent reports whether any logged-in marker is on the page
+func (m *Manager) markersPresent(ctx context.Context) bool {
+	var present bool
+	err := chromedp.Run(ctx, chromedp.Evaluate(m.sel.AnyExistsJS(selectors.LoggedInMarkers), &present))
+	return err == nil && present
+}
+
+// cookieUserID returns the user ID from the SPC_U cookie, 0 when anonymous
+func cookieUserID(ctx context.Context) int64 {
+	var cookies []*network.Cookie
+	err := chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		var err error
+		cookies, err = network.GetCookies().Do(ctx)
+		return err
+	}))
+	if err != nil {
+		return 0
+	}
+
+	for _, c := range cookies {
+		if c.Name == userCookie {
+			id, _ := strconv.ParseInt(c.Value, 10, 64)
+			return id
+		}
+	}
+	return 0
+}
@@ -0,0 +1,311 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/LLionNg/shopee-livestream-bot/internal/config"
+	"github.com/LLionNg/shopee-livestream-bot/internal/console"
+	"github.com/chromedp/cdproto/network"
+)
+
+// KeyEnv is the environment variable holding the session encryption key
+const KeyEnv = "SHOPEE_SESSION_KEY"
+
+// legacySessionFile is where sessions were written before the path became
+// configurable
+const legacySessionFile = "data/cookies/session.json"
+
+// legacyKeyFile is where the session key was generated before it moved out
+// of data/
+const legacyKeyFile = "data/keys/session.key"
+
+// sessionFormat identifies the encrypted file layout; it is also bound into
+// the ciphertext so a file can't be passed off as another format
+const sessionFormat = "shopee-session/aes-256-gcm/v1"
+
+var (
+	// ErrNoSession means no saved session exists yet
+	ErrNoSession = errors.New("no saved session")
+	// ErrSessionTampered means the session file failed authentication
+	ErrSessionTampered = errors.New("session file was modified or encrypted with a different key")
+	// ErrSessionExpired means the saved login cookies have expired
+	ErrSessionExpired = errors.New("saved session has expired")
+)
+
+// sealedFile is the on-disk layout of an encrypted session
+type sealedFile struct {
+	Format string `json:"format"`
+	Nonce  string `json:"nonce"`
+	Data   string `json:"data"`
+}
+
+// SessionStore keeps session cookies encrypted at rest with AES-GCM
+type SessionStore struct {
+	path string
+	aead cipher.AEAD
+}
+
+// NewSessionStore creates a store for path using a 32-byte key
+func NewSessionStore(path string, key []byte) (*SessionStore, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid session key: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &SessionStore{path: path, aead: aead}, nil
+}
+
+// OpenSessionStore creates the store described by the auth config and
+// migrates a plaintext session left by an older version
+func OpenSessionStore(cfg *config.AuthConfig) (*SessionStore, error) {
+	key, err := LoadKey(cfg.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	s, err := NewSessionStore(cfg.SessionFile, key)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.migrate(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// LoadKey returns the session key from $SHOPEE_SESSION_KEY (or the file
+// named by $SHOPEE_SESSION_KEY_FILE) or keyFile. Keys are 32 bytes, hex or
+// base64 encoded. When neither is set a key left in data/ by an older
+// version is moved to keyFile, or else a random key is generated there.
+func LoadKey(keyFile string) ([]byte, error) {
+	value, _, err := config.LookupEnv(KeyEnv)
+	if err != nil {
+		return nil, err
+	}
+	if value != "" {
+		key, err := decodeKey(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", KeyEnv, err)
+		}
+		return key, nil
+	}
+
+	if keyFile == "" {
+		return nil, fmt.Errorf("no session key: set %s or auth.key_file", KeyEnv)
+	}
+	if err := moveLegacyKey(keyFile); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(keyFile)
+	if err == nil {
+		key, err := decodeKey(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", keyFile, err)
+		}
+		return key, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read session key: %w", err)
+	}
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate session key: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(keyFile), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create key directory: %w", err)
+	}
+	if err := os.WriteFile(keyFile, []byte(hex.EncodeToString(key)+"\n"), 0600); err != nil {
+		return nil, fmt.Errorf("failed to write session key: %w", err)
+	}
+	console.Printf("🔑 Generated a new session key: %s\n", keyFile)
+	return key, nil
+}
+
+// moveLegacyKey moves a key generated in data/ by an older version to
+// keyFile, so sessions it encrypted stay readable
+func moveLegacyKey(keyFile string) error {
+	if _, err := os.Stat(keyFile); !os.IsNotExist(err) {
+		return nil
+	}
+	data, err := os.ReadFile(legacyKeyFile)
+	if err != nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(keyFile), 0700); err != nil {
+		return fmt.Errorf("failed to create key directory: %w", err)
+	}
+	if err := os.WriteFile(keyFile, data, 0600); err != nil {
+		return fmt.Errorf("failed to move session key: %w", err)
+	}
+	os.Remove(legacyKeyFile)
+	console.Printf("🔑 Moved the session key out of data/: %s -> %s\n", legacyKeyFile, keyFile)
+	return nil
+}
+
+// decodeKey accepts a 32-byte key as hex or base64
+func decodeKey(value string) ([]byte, error) {
+	value = strings.TrimSpace(value)
+	if key, err := hex.DecodeString(value); err == nil && len(key) == 32 {
+		return key, nil
+	}
+	if key, err := base64.StdEncoding.DecodeString(value); err == nil && len(key) == 32 {
+		return key, nil
+	}
+	return nil, fmt.Errorf("session key must be 32 bytes, hex or base64 encoded (e.g. openssl rand -hex 32)")
+}
+
+// Path returns the session file path
+func (s *SessionStore) Path() string {
+	return s.path
+}
+
+// Save encrypts and writes the cookies
+func (s *SessionStore) Save(cookies []*network.Cookie) error {
+	plain, err := json.Marshal(cookies)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cookies: %w", err)
+	}
+	data, err := s.seal(sessionFormat, plain)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(s.path, data); err != nil {
+		return fmt.Errorf("failed to write session file: %w", err)
+	}
+	return nil
+}
+
+// Load reads and decrypts the cookies. It returns ErrNoSession when there is
+// no file and ErrSessionTampered when the file fails authentication.
+func (s *SessionStore) Load() ([]*network.Cookie, error) {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, ErrNoSession
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session file: %w", err)
+	}
+
+	if isPlaintext(data) {
+		return nil, fmt.Errorf("session file %s is not encrypted", s.path)
+	}
+
+	plain, err := s.open(sessionFormat, data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", s.path, err)
+	}
+
+	var cookies []*network.Cookie
+	if err := json.Unmarshal(plain, &cookies); err != nil {
+		return nil, fmt.Errorf("failed to parse session: %w", err)
+	}
+	return cookies, nil
+}
+
+// seal encrypts plain into a sealedFile document of the given format
+func (s *SessionStore) seal(format string, plain []byte) ([]byte, error) {
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := sealedFile{
+		Format: format,
+		Nonce:  base64.StdEncoding.EncodeToString(nonce),
+		Data:   base64.StdEncoding.EncodeToString(s.aead.Seal(nil, nonce, plain, []byte(format))),
+	}
+	return json.MarshalIndent(sealed, "", "  ")
+}
+
+// open decrypts a sealedFile document, returning ErrSessionTampered when it
+// is not of the given format or fails authentication
+func (s *SessionStore) open(format string, data []byte) ([]byte, error) {
+	var sealed sealedFile
+	if err := json.Unmarshal(data, &sealed); err != nil || sealed.Format != format {
+		return nil, ErrSessionTampered
+	}
+	nonce, err1 := base64.StdEncoding.DecodeString(sealed.Nonce)
+	ciphertext, err2 := base64.StdEncoding.DecodeString(sealed.Data)
+	if err1 != nil || err2 != nil || len(nonce) != s.aead.NonceSize() {
+		return nil, ErrSessionTampered
+	}
+
+	plain, err := s.aead.Open(nil, nonce, ciphertext, []byte(format))
+	if err != nil {
+		return nil, ErrSessionTampered
+	}
+	return plain, nil
+}
+
+// writeFileAtomic writes through a temp file so a crash can't leave half a
+// file behind
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// Remove deletes the session file
+func (s *SessionStore) Remove() error {
+	err := os.Remove(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// migrate encrypts a plaintext cookie file written by an older version,
+// either in place or from the old hard-coded location
+func (s *SessionStore) migrate() error {
+	source := s.path
+	if _, err := os.Stat(source); os.IsNotExist(err) {
+		source = legacySessionFile
+	}
+
+	data, err := os.ReadFile(source)
+	if err != nil || !isPlaintext(data) {
+		return nil
+	}
+
+	var cookies []*network.Cookie
+	if err := json.Unmarshal(data, &cookies); err != nil {
+		return fmt.Errorf("failed to migrate plaintext session %s: %w", source, err)
+	}
+	if err := s.Save(cookies); err != nil {
+		return fmt.Errorf("failed to migrate plaintext session %s: %w", source, err)
+	}
+	if source != s.path {
+		os.Remove(source)
+	}
+
+	console.Printf("🔒 Encrypted plaintext session %s -> %s\n", source, s.path)
+	return nil
+}
+
+// isPlaintext reports whether data is a cookie list saved before encryption
+func isPlaintext(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte("["))
+}
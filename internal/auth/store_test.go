@@ -0,0 +1,162 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/LLionNg/shopee-livestream-bot/internal/config"
+	"github.com/chromedp/cdproto/network"
+)
+
+// testCookies are shaped like the cookies Chrome returns
+var testCookies = []*network.Cookie{
+	{Name: "SPC_U", Value: "123456", Domain: ".shopee.co.th", Expires: 1893456000,
+		Priority: network.CookiePriorityMedium, SourceScheme: network.CookieSourceSchemeSecure},
+	{Name: "SPC_EC", Value: "secret", Domain: ".shopee.co.th", HTTPOnly: true,
+		Priority: network.CookiePriorityMedium, SourceScheme: network.CookieSourceSchemeSecure},
+}
+
+func testStore(t *testing.T, path string, fill byte) *SessionStore {
+	t.Helper()
+	s, err := NewSessionStore(path, bytes.Repeat([]byte{fill}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestSessionStoreRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.json")
+	s := testStore(t, path, 1)
+
+	if _, err := s.Load(); !errors.Is(err, ErrNoSession) {
+		t.Fatalf("Load before Save: got %v, want ErrNoSession", err)
+	}
+	if err := s.Save(testCookies); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("secret")) || isPlaintext(data) {
+		t.Errorf("session file holds the cookies in plaintext:\n%s", data)
+	}
+
+	got, err := s.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(got) != 2 || got[0].Name != "SPC_U" || got[1].Value != "secret" {
+		t.Errorf("Load = %+v, want the saved cookies", got)
+	}
+}
+
+func TestSessionStoreTampered(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.json")
+	s := testStore(t, path, 1)
+	if err := s.Save(testCookies); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sealed sealedFile
+	if err := json.Unmarshal(data, &sealed); err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]func(*sealedFile){
+		"flipped byte": func(f *sealedFile) {
+			b := []byte(f.Data)
+			if b[0] == 'A' {
+				b[0] = 'B'
+			} else {
+				b[0] = 'A'
+			}
+			f.Data = string(b)
+		},
+		"other format": func(f *sealedFile) { f.Format = "shopee-bundle/aes-256-gcm/v1" },
+		"bad nonce":    func(f *sealedFile) { f.Nonce = "AAAA" },
+	}
+	for name, tamper := range tests {
+		f := sealed
+		tamper(&f)
+		data, _ := json.Marshal(f)
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.Load(); !errors.Is(err, ErrSessionTampered) {
+			t.Errorf("%s: got %v, want ErrSessionTampered", name, err)
+		}
+	}
+}
+
+func TestSessionStoreWrongKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.json")
+	if err := testStore(t, path, 1).Save(testCookies); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := testStore(t, path, 2).Load(); !errors.Is(err, ErrSessionTampered) {
+		t.Errorf("Load with another key: got %v, want ErrSessionTampered", err)
+	}
+}
+
+func TestOpenSessionStoreMigratesPlaintext(t *testing.T) {
+	t.Setenv(KeyEnv, "")
+	dir := t.TempDir()
+	cfg := &config.AuthConfig{
+		SessionFile: filepath.Join(dir, "session.json"),
+		KeyFile:     filepath.Join(dir, "keys", "session.key"),
+	}
+	plain, _ := json.Marshal(testCookies)
+	if err := os.WriteFile(cfg.SessionFile, plain, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := OpenSessionStore(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(cfg.SessionFile)
+	if isPlaintext(data) {
+		t.Fatal("session file was not encrypted")
+	}
+	if got, err := s.Load(); err != nil || len(got) != 2 {
+		t.Errorf("Load after migration = %+v, %v", got, err)
+	}
+
+	// The generated key is reused on the next start
+	s, err = OpenSessionStore(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Load(); err != nil {
+		t.Errorf("Load with the saved key: %v", err)
+	}
+}
+
+func TestLoadKey(t *testing.T) {
+	hexKey := "0101010101010101010101010101010101010101010101010101010101010101"
+	t.Setenv(KeyEnv, hexKey)
+	key, err := LoadKey("")
+	if err != nil || !bytes.Equal(key, bytes.Repeat([]byte{1}, 32)) {
+		t.Errorf("LoadKey from %s = %x, %v", KeyEnv, key, err)
+	}
+
+	t.Setenv(KeyEnv, "too short")
+	if _, err := LoadKey(""); err == nil {
+		t.Error("LoadKey accepted a short key")
+	}
+
+	t.Setenv(KeyEnv, "")
+	if _, err := LoadKey(""); err == nil {
+		t.Error("LoadKey without a key or key file should fail")
+	}
+}
@@ -0,0 +1,138 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/LLionNg/shopee-livestream-bot/internal/console"
+	"github.com/LLionNg/shopee-livestream-bot/internal/notify"
+)
+
+// Pauser is something that must stop acting while the session is lost,
+// e.g. the purchase executor
+type Pauser interface {
+	Pause(reason string)
+	Resume()
+}
+
+// SessionSupervisor keeps the login alive during long runs. It validates the
+// session periodically and when a stream tab reports a login redirect; on
+// loss it pauses purchasing, logs in again, saves the cookies and resumes.
+type SessionSupervisor struct {
+	m        *Manager
+	interval time.Duration
+	pauser   Pauser
+	notifier *notify.Notifier
+
+	lost chan string
+
+	mu    sync.Mutex
+	ready chan struct{}
+}
+
+// NewSessionSupervisor creates a supervisor for a logged-in manager
+func NewSessionSupervisor(m *Manager, interval time.Duration, pauser Pauser, n *notify.Notifier) *SessionSupervisor {
+	ready := make(chan struct{})
+	close(ready)
+	return &SessionSupervisor{
+		m:        m,
+		interval: interval,
+		pauser:   pauser,
+		notifier: n,
+		lost:     make(chan string, 1),
+		ready:    ready,
+	}
+}
+
+// Ready returns a channel that is closed while the session is valid. Stream
+// tabs that were bounced to the login page wait on it before reloading.
+func (s *SessionSupervisor) Ready() <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ready
+}
+
+// ReportLoggedOut tells the supervisor a tab was redirected to the login page
+func (s *SessionSupervisor) ReportLoggedOut(reason string) {
+	select {
+	case s.lost <- reason:
+	default:
+	}
+}
+
+// Run validates the session every interval and re-authenticates after a
+// loss until ctx is done
+func (s *SessionSupervisor) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		var reason string
+		select {
+		case <-ctx.Done():
+			return
+		case reason = <-s.lost:
+		case <-ticker.C:
+			// A failed check during shutdown is not a lost session
+			if s.m.checkSession(ctx) || ctx.Err() != nil {
+				continue
+			}
+			reason = "periodic validation failed"
+		}
+
+		s.recover(ctx, reason)
+	}
+}
+
+// recover pauses purchasing and logs in again, retrying every interval
+// until it works or ctx is done
+func (s *SessionSupervisor) recover(ctx context.Context, reason string) {
+	s.mu.Lock()
+	select {
+	case <-s.ready:
+		s.ready = make(chan struct{})
+	default:
+	}
+	s.mu.Unlock()
+
+	s.pauser.Pause("session lost: " + reason)
+	s.send(ctx, notify.Warning, "Session lost", reason+"; purchasing paused, logging in again")
+
+	for {
+		err := s.m.relogin(ctx, func() {
+			s.send(ctx, notify.Critical, "Manual login required", "no credentials configured, please log in in the bot's browser window")
+		})
+		if err == nil {
+			break
+		}
+		s.send(ctx, notify.Critical, "Re-login failed", fmt.Sprintf("%v; retrying in %s", err, s.interval))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(s.interval):
+		}
+	}
+
+	// Drop reports from tabs that bounced before the new login
+	select {
+	case <-s.lost:
+	default:
+	}
+
+	s.mu.Lock()
+	close(s.ready)
+	s.mu.Unlock()
+
+	s.pauser.Resume()
+	s.send(ctx, notify.Info, "Session restored", s.m.User().String()+", purchasing resumed")
+}
+
+// send delivers a notification, reporting delivery problems on the console
+func (s *SessionSupervisor) send(ctx context.Context, level notify.Level, title, message string) {
+	if err := s.notifier.Send(ctx, level, title, message); err != nil {
+		console.Printf("⚠️  %v\n", err)
+	}
+}
@@ -0,0 +1,131 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/LLionNg/shopee-livestream-bot/internal/browser"
+	"github.com/LLionNg/shopee-livestream-bot/internal/console"
+	"github.com/LLionNg/shopee-livestream-bot/internal/selectors"
+	"github.com/chromedp/chromedp"
+)
+
+// maxCodeAttempts is how many codes are tried before giving up
+const maxCodeAttempts = 3
+
+// SetCodeSource sets where verification codes are asked for. Without one,
+// a verification step fails the login.
+func (m *Manager) SetCodeSource(src CodeSource) {
+	m.codes = src
+}
+
+// detectChallenge reports which verification step, if any, the login page
+// is showing
+func (m *Manager) detectChallenge(ctx context.Context) (Challenge, bool) {
+	switch {
+	case m.sel.Exists(ctx, selectors.VerifyCodeInput):
+		return Challenge{
+			Kind:   ChallengeOTP,
+			Prompt: "Shopee sent a verification code (SMS/email/app). Reply with the code.",
+		}, true
+	case m.sel.Exists(ctx, selectors.VerifyEmailNotice):
+		return Challenge{
+			Kind:   ChallengeEmailLink,
+			Prompt: "Shopee sent a verification link by email. Reply with the link, or reply \"done\" after opening it on any device.",
+		}, true
+	}
+	return Challenge{}, false
+}
+
+// handleVerification answers a verification step after the login form was
+// submitted. It returns nil straight away when there is none.
+func (m *Manager) handleVerification(ctx context.Context) error {
+	ch, ok := m.detectChallenge(ctx)
+	if !ok {
+		return nil
+	}
+	if m.codes == nil {
+		return fmt.Errorf("login needs %s verification but no verification source is configured", ch.Kind)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, m.cfg.Auth.Verification.GetTimeout())
+	defer cancel()
+
+	console.Printf("🔐 Login needs %s verification, asking the operator (timeout %s)...\n", ch.Kind, m.cfg.Auth.Verification.GetTimeout())
+
+	if ch.Kind == ChallengeEmailLink {
+		return m.answerEmailLink(ctx, ch)
+	}
+	return m.answerOTP(ctx, ch)
+}
+
+// answerOTP types codes from the operator until the code field goes away
+func (m *Manager) answerOTP(ctx context.Context, ch Challenge) error {
+	for attempt := 1; attempt <= maxCodeAttempts; attempt++ {
+		code, err := m.codes.Code(ctx, ch)
+		if err != nil {
+			return fmt.Errorf("no verification code received: %w", err)
+		}
+
+		input, err := m.sel.Find(ctx, selectors.VerifyCodeInput)
+		if err != nil {
+			// The step went away on its own (e.g. approved in the app)
+			return nil
+		}
+		if err := browser.Type(ctx, input, code); err != nil {
+			return fmt.Errorf("failed to enter verification code: %w", err)
+		}
+		if submit, err := m.sel.Find(ctx, selectors.VerifyCodeSubmit); err == nil {
+			if err := browser.Click(ctx, submit); err != nil {
+				return fmt.Errorf("failed to submit verification code: %w", err)
+			}
+		}
+
+		if err := browser.Sleep(ctx, 3*time.Second); err != nil {
+			return fmt.Errorf("verification not completed: %w", err)
+		}
+		if !m.sel.Exists(ctx, selectors.VerifyCodeInput) {
+			console.Println("✅ Verification code accepted")
+			return nil
+		}
+
+		console.Printf("⚠️  Verification code rejected (attempt %d/%d)\n", attempt, maxCodeAttempts)
+		ch.Prompt = "That code was not accepted. Reply with the new code."
+	}
+	return fmt.Errorf("verification failed after %d codes", maxCodeAttempts)
+}
+
+// answerEmailLink opens the link from the operator, or waits for it to be
+// opened elsewhere, until the login page moves on
+func (m *Manager) answerEmailLink(ctx context.Context, ch Challenge) error {
+	answer, err := m.codes.Code(ctx, ch)
+	if err != nil {
+		return fmt.Errorf("no verification link received: %w", err)
+	}
+
+	if strings.HasPrefix(answer, "http://") || strings.HasPrefix(answer, "https://") {
+		// Open the link in its own tab so the login tab can carry on
+		tabCtx, cancel := chromedp.NewContext(ctx)
+		err := browser.NavigateWithRetry(tabCtx, answer, 3)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("failed to open verification link: %w", err)
+		}
+	}
+
+	// The login page notices the verification by itself; reload if it doesn't
+	for {
+		if !m.sel.Exists(ctx, selectors.VerifyEmailNotice) {
+			console.Println("✅ Email verification completed")
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("email verification not completed: %w", ctx.Err())
+		case <-time.After(5 * time.Second):
+			chromedp.Run(ctx, chromedp.Reload())
+		}
+	}
+}
@@ -1,90 +1,20 @@
 package browser
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"image/jpeg"
+	"image/png"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/chromedp/chromedp"
-	"github.com/LLionNg/shopee-livestream-bot/internal/config"
-)
-
-// Initialize creates and configures a browser context
-func Initialize(ctx context.Context, cfg *config.Config) (context.Context, context.CancelFunc) {
-	// Create user data directory if it doesn't exist
-	if cfg.Browser.UserDataDir != "" {
-		if err := ensureDir(cfg.Browser.UserDataDir); err != nil {
-			fmt.Printf("Warning: Failed to create user data directory: %v\n", err)
-		}
-	}
-
-	// Find Chrome executable path (Windows-specific)
-	chromePath := findChrome()
-	if chromePath != "" {
-		fmt.Printf("Found Chrome at: %s\n", chromePath)
-	}
-
-	// Build Chrome options from scratch to have full control
-	opts := []chromedp.ExecAllocatorOption{
-		chromedp.NoFirstRun,
-		chromedp.NoDefaultBrowserCheck,
-		chromedp.Flag("disable-blink-features", "AutomationControlled"),
-		chromedp.Flag("enable-automation", false),
-		chromedp.UserAgent("Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"),
-		chromedp.WindowSize(cfg.Browser.Viewport.Width, cfg.Browser.Viewport.Height),
-	}
-
-	// Add Chrome executable path if found
-	if chromePath != "" {
-		opts = append(opts, chromedp.ExecPath(chromePath))
-	}
-
-	// Explicitly control headless mode
-	if cfg.Browser.Headless {
-		fmt.Println("Running in HEADLESS mode")
-		opts = append(opts, chromedp.Flag("headless", true))
-	} else {
-		fmt.Println("Running in VISIBLE mode (window should appear)")
-		// Explicitly disable headless to ensure window shows
-		opts = append(opts, chromedp.Flag("headless", false))
-	}
 
-	// Add user data directory for session persistence
-	if cfg.Browser.UserDataDir != "" {
-		opts = append(opts, chromedp.UserDataDir(cfg.Browser.UserDataDir))
-	}
-
-	// Create allocator context
-	allocCtx, allocCancel := chromedp.NewExecAllocator(ctx, opts...)
-
-	// Create browser context
-	browserCtx, browserCancel := chromedp.NewContext(allocCtx, chromedp.WithLogf(func(string, ...interface{}) {}))
-
-	// Actually start the browser and navigate to a page to make window visible
-	// This ensures Chrome is launched and visible before we return
-	fmt.Println("Launching Chrome browser and opening window...")
-	err := chromedp.Run(browserCtx,
-		chromedp.Navigate("about:blank"),
-		chromedp.Sleep(500*time.Millisecond), // Give window time to appear
-	)
-	if err != nil {
-		browserCancel()
-		allocCancel()
-		fmt.Printf("❌ Failed to start browser: %v\n", err)
-		fmt.Println("Make sure Chrome is installed and accessible")
-		return nil, func() {}
-	}
-	fmt.Println("✅ Chrome browser window should now be visible")
-
-	// Return a combined cancel function that cleans up all contexts
-	combinedCancel := func() {
-		browserCancel()
-		allocCancel()
-	}
-
-	return browserCtx, combinedCancel
-}
+	"github.com/LLionNg/shopee-livestream-bot/internal/console"
+)
 
 // getStealthOptions returns options to avoid bot detection
 func getStealthOptions() []chromedp.ExecAllocatorOption {
@@ -93,9 +23,6 @@ func getStealthOptions() []chromedp.ExecAllocatorOption {
 		chromedp.Flag("enable-automation", false),
 		chromedp.Flag("disable-blink-features", "AutomationControlled"),
 
-		// Set realistic user agent
-		chromedp.UserAgent("Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"),
-
 		// Additional anti-detection flags
 		chromedp.Flag("disable-dev-shm-usage", true),
 		chromedp.Flag("disable-background-networking", true),
@@ -127,16 +54,45 @@ func NavigateWithRetry(ctx context.Context, url string, maxRetries int) error {
 		if err == nil {
 			return nil
 		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
 
-		fmt.Printf("Navigation attempt %d/%d failed: %v\n", i+1, maxRetries, err)
+		console.Printf("Navigation attempt %d/%d failed: %v\n", i+1, maxRetries, err)
 
 		if i < maxRetries-1 {
-			time.Sleep(time.Duration(i+1) * time.Second)
+			if err := Sleep(ctx, time.Duration(i+1)*time.Second); err != nil {
+				return err
+			}
 		}
 	}
 	return fmt.Errorf("failed to navigate to %s after %d retries: %w", url, maxRetries, err)
 }
 
+// Sleep waits for d, returning early with ctx's error once ctx is done
+func Sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Bind returns a context for working in tab that is also cancelled when ctx
+// is done, so a caller's cancellation interrupts work in a tab it doesn't
+// own. Cancelling the result leaves the tab open.
+func Bind(ctx, tab context.Context) (context.Context, context.CancelFunc) {
+	bound, cancel := context.WithCancel(tab)
+	stop := context.AfterFunc(ctx, cancel)
+	return bound, func() {
+		stop()
+		cancel()
+	}
+}
+
 // WaitForElement waits for an element to be visible
 func WaitForElement(ctx context.Context, selector string, timeout time.Duration) error {
 	ctx, cancel := context.WithTimeout(ctx, timeout)
@@ -173,15 +129,64 @@ func GetText(ctx context.Context, selector string) (string, error) {
 	return text, err
 }
 
-// Screenshot takes a screenshot of the current page
-func Screenshot(ctx context.Context, filepath string) error {
+// ScreenshotMode selects what part of the page is captured
+type ScreenshotMode int
+
+const (
+	// ScreenshotViewport captures the visible part of the page
+	ScreenshotViewport ScreenshotMode = iota
+	// ScreenshotFullPage captures the whole scrollable page
+	ScreenshotFullPage
+	// ScreenshotElement captures only the element matching a selector
+	ScreenshotElement
+)
+
+// jpegQuality is used when a screenshot is written as .jpg/.jpeg
+const jpegQuality = 90
+
+// Screenshot takes a screenshot of the current page and writes it to
+// path. The format follows the extension: .jpg/.jpeg for JPEG,
+// anything else for PNG. selector is only used in ScreenshotElement mode.
+func Screenshot(ctx context.Context, path string, mode ScreenshotMode, selector string) error {
 	var buf []byte
-	err := chromedp.Run(ctx, chromedp.CaptureScreenshot(&buf))
-	if err != nil {
-		return err
+	var action chromedp.Action
+
+	switch mode {
+	case ScreenshotFullPage:
+		action = chromedp.FullScreenshot(&buf, 100)
+	case ScreenshotElement:
+		if selector == "" {
+			return fmt.Errorf("element screenshot requires a selector")
+		}
+		action = chromedp.Screenshot(selector, &buf, chromedp.ByQuery)
+	default:
+		action = chromedp.CaptureScreenshot(&buf)
+	}
+
+	if err := chromedp.Run(ctx, action); err != nil {
+		return fmt.Errorf("failed to capture screenshot: %w", err)
 	}
 
-	// Save to file (implement file writing here if needed)
+	// Chrome returns PNG; re-encode when JPEG was asked for
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == ".jpg" || ext == ".jpeg" {
+		img, err := png.Decode(bytes.NewReader(buf))
+		if err != nil {
+			return fmt.Errorf("failed to decode screenshot: %w", err)
+		}
+		var out bytes.Buffer
+		if err := jpeg.Encode(&out, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+			return fmt.Errorf("failed to encode screenshot: %w", err)
+		}
+		buf = out.Bytes()
+	}
+
+	if err := ensureDir(filepath.Dir(path)); err != nil {
+		return fmt.Errorf("failed to create screenshot directory: %w", err)
+	}
+	if err := os.WriteFile(path, buf, 0644); err != nil {
+		return fmt.Errorf("failed to write screenshot: %w", err)
+	}
 	return nil
 }
 
@@ -248,23 +253,3 @@ func ensureDir(dir string) error {
 	}
 	return nil
 }
-
-// findChrome attempts to find Chrome executable on Windows
-func findChrome() string {
-	// Common Chrome installation paths on Windows
-	paths := []string{
-		os.Getenv("PROGRAMFILES") + "\\Google\\Chrome\\Application\\chrome.exe",
-		os.Getenv("PROGRAMFILES(X86)") + "\\Google\\Chrome\\Application\\chrome.exe",
-		os.Getenv("LOCALAPPDATA") + "\\Google\\Chrome\\Application\\chrome.exe",
-		"C:\\Program Files\\Google\\Chrome\\Application\\chrome.exe",
-		"C:\\Program Files (x86)\\Google\\Chrome\\Application\\chrome.exe",
-	}
-
-	for _, path := range paths {
-		if _, err := os.Stat(path); err == nil {
-			return path
-		}
-	}
-
-	return "" // Let chromedp use default search
-}
\ No newline at end of file
@@ -0,0 +1,131 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	cdpbrowser "github.com/chromedp/cdproto/browser"
+	"github.com/chromedp/chromedp"
+)
+
+// ProtocolVersion is the DevTools protocol version the bundled cdproto speaks
+const ProtocolVersion = "1.3"
+
+// MinChromeMajor is the oldest Chrome release the bundled cdproto was
+// generated against; older browsers may lack commands the bot uses
+const MinChromeMajor = 120
+
+// FindChrome returns the Chrome executable to launch. A configured path must
+// exist; otherwise well-known install locations for the current OS are
+// searched. An empty result lets chromedp fall back to its own lookup.
+func FindChrome(execPath string) (string, error) {
+	if execPath != "" {
+		if _, err := os.Stat(execPath); err == nil {
+			return execPath, nil
+		}
+		if path, err := exec.LookPath(execPath); err == nil {
+			return path, nil
+		}
+		return "", fmt.Errorf("browser.exec_path %q not found", execPath)
+	}
+
+	for _, name := range chromeNames() {
+		if path, err := exec.LookPath(name); err == nil {
+			return path, nil
+		}
+	}
+
+	for _, path := range chromePaths() {
+		if path == "" {
+			continue
+		}
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+
+	return "", nil
+}
+
+// ChromeVersion runs "<chrome> --version" and returns its output
+func ChromeVersion(execPath string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, execPath, "--version").Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// VersionInfo is what a running browser reports about itself
+type VersionInfo struct {
+	ProtocolVersion string
+	Product         string
+	Revision        string
+	UserAgent       string
+	JSVersion       string
+}
+
+// Major returns the major Chrome version from Product (e.g. "Chrome/120.0...")
+func (v *VersionInfo) Major() int {
+	_, version, _ := strings.Cut(v.Product, "/")
+	major, _ := strconv.Atoi(strings.Split(version, ".")[0])
+	return major
+}
+
+// Compatible reports whether the browser speaks the protocol cdproto expects
+func (v *VersionInfo) Compatible() error {
+	if v.ProtocolVersion != ProtocolVersion {
+		return fmt.Errorf("DevTools protocol %s, expected %s", v.ProtocolVersion, ProtocolVersion)
+	}
+	if major := v.Major(); major != 0 && major < MinChromeMajor {
+		return fmt.Errorf("%s is older than Chrome %d", v.Product, MinChromeMajor)
+	}
+	return nil
+}
+
+// GetVersion asks the browser behind ctx for its version information
+func GetVersion(ctx context.Context) (*VersionInfo, error) {
+	var v VersionInfo
+	err := chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		var err error
+		v.ProtocolVersion, v.Product, v.Revision, v.UserAgent, v.JSVersion, err = cdpbrowser.GetVersion().Do(ctx)
+		return err
+	}))
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// Probe launches a throwaway headless browser from execPath and reports its version
+func Probe(ctx context.Context, execPath string) (*VersionInfo, error) {
+	dir, err := os.MkdirTemp("", "shopee-bot-probe-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	opts := append(chromedp.DefaultExecAllocatorOptions[:], chromedp.UserDataDir(dir))
+	if execPath != "" {
+		opts = append(opts, chromedp.ExecPath(execPath))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	allocCtx, allocCancel := chromedp.NewExecAllocator(ctx, opts...)
+	defer allocCancel()
+
+	browserCtx, browserCancel := chromedp.NewContext(allocCtx)
+	defer browserCancel()
+
+	return GetVersion(browserCtx)
+}
@@ -0,0 +1,27 @@
+package browser
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// chromeNames returns executable names searched for in PATH
+func chromeNames() []string {
+	return []string{"google-chrome", "chromium", "chrome"}
+}
+
+// chromePaths returns well-known Chrome install locations on macOS
+func chromePaths() []string {
+	paths := []string{
+		"/Applications/Google Chrome.app/Contents/MacOS/Google Chrome",
+		"/Applications/Chromium.app/Contents/MacOS/Chromium",
+		"/Applications/Google Chrome Canary.app/Contents/MacOS/Google Chrome Canary",
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		paths = append(paths,
+			filepath.Join(home, "Applications/Google Chrome.app/Contents/MacOS/Google Chrome"),
+			filepath.Join(home, "Applications/Chromium.app/Contents/MacOS/Chromium"),
+		)
+	}
+	return paths
+}
@@ -0,0 +1,41 @@
+package browser
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// chromeNames returns executable names searched for in PATH
+func chromeNames() []string {
+	return []string{
+		"google-chrome",
+		"google-chrome-stable",
+		"chromium",
+		"chromium-browser",
+		"chrome",
+		"headless-shell",
+	}
+}
+
+// chromePaths returns well-known Chrome install locations on Linux,
+// including snap and flatpak exports
+func chromePaths() []string {
+	home, _ := os.UserHomeDir()
+	paths := []string{
+		"/opt/google/chrome/chrome",
+		"/usr/bin/google-chrome",
+		"/usr/bin/chromium",
+		"/usr/bin/chromium-browser",
+		"/snap/bin/chromium",
+		"/var/lib/snapd/snap/bin/chromium",
+		"/var/lib/flatpak/exports/bin/com.google.Chrome",
+		"/var/lib/flatpak/exports/bin/org.chromium.Chromium",
+	}
+	if home != "" {
+		paths = append(paths,
+			filepath.Join(home, ".local/share/flatpak/exports/bin/com.google.Chrome"),
+			filepath.Join(home, ".local/share/flatpak/exports/bin/org.chromium.Chromium"),
+		)
+	}
+	return paths
+}
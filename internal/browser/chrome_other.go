@@ -0,0 +1,13 @@
+//go:build !linux && !darwin && !windows
+
+package browser
+
+// chromeNames returns executable names searched for in PATH
+func chromeNames() []string {
+	return []string{"google-chrome", "chromium", "chromium-browser", "chrome"}
+}
+
+// chromePaths returns no fixed locations on other systems
+func chromePaths() []string {
+	return nil
+}
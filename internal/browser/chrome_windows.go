@@ -0,0 +1,19 @@
+package browser
+
+import "os"
+
+// chromeNames returns executable names searched for in PATH
+func chromeNames() []string {
+	return []string{"chrome.exe"}
+}
+
+// chromePaths returns common Chrome installation paths on Windows
+func chromePaths() []string {
+	return []string{
+		os.Getenv("PROGRAMFILES") + "\\Google\\Chrome\\Application\\chrome.exe",
+		os.Getenv("PROGRAMFILES(X86)") + "\\Google\\Chrome\\Application\\chrome.exe",
+		os.Getenv("LOCALAPPDATA") + "\\Google\\Chrome\\Application\\chrome.exe",
+		"C:\\Program Files\\Google\\Chrome\\Application\\chrome.exe",
+		"C:\\Program Files (x86)\\Google\\Chrome\\Application\\chrome.exe",
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !unix && !windows
+
+package browser
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// profileLock treats any lock file as held on systems without a process check
+func profileLock(dir string) LockInfo {
+	for _, name := range []string{"SingletonLock", "lockfile"} {
+		if _, err := os.Lstat(filepath.Join(dir, name)); err == nil {
+			return LockInfo{Held: true}
+		}
+	}
+	return LockInfo{}
+}
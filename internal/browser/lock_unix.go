@@ -0,0 +1,42 @@
+//go:build unix
+
+package browser
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// profileLock reads Chrome's SingletonLock symlink, whose target is
+// "<hostname>-<pid>", and checks whether that process is still alive
+func profileLock(dir string) LockInfo {
+	target, err := os.Readlink(filepath.Join(dir, "SingletonLock"))
+	if err != nil {
+		return LockInfo{}
+	}
+
+	i := strings.LastIndex(target, "-")
+	if i < 0 {
+		return LockInfo{Held: true}
+	}
+	lock := LockInfo{Held: true, Host: target[:i]}
+	lock.PID, _ = strconv.Atoi(target[i+1:])
+
+	// A process on another machine (shared home directory) can't be checked
+	if host, _ := os.Hostname(); host != lock.Host || lock.PID == 0 {
+		return lock
+	}
+
+	lock.Held = processAlive(lock.PID)
+	return lock
+}
+
+// processAlive reports whether a process with pid exists
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,22 @@
+package browser
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// profileLock checks Chrome's lockfile, which a running Chrome keeps open
+// without sharing; a leftover lockfile can be opened
+func profileLock(dir string) LockInfo {
+	path := filepath.Join(dir, "lockfile")
+	if _, err := os.Stat(path); err != nil {
+		return LockInfo{}
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return LockInfo{Held: true}
+	}
+	f.Close()
+	return LockInfo{}
+}
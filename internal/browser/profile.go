@@ -0,0 +1,228 @@
+package browser
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// profileSkip lists profile entries that are not copied when cloning a
+// template: Chrome's instance locks and caches it rebuilds on its own
+var profileSkip = map[string]bool{
+	"SingletonLock":   true,
+	"SingletonSocket": true,
+	"SingletonCookie": true,
+	"lockfile":        true,
+	"Cache":           true,
+	"Code Cache":      true,
+	"GPUCache":        true,
+	"ShaderCache":     true,
+	"GrShaderCache":   true,
+	"Crashpad":        true,
+}
+
+// runsDir holds per-run clones inside the profiles directory
+const runsDir = ".runs"
+
+// staleCloneAge is how old an unlocked clone must be before it counts as
+// left behind by a run that didn't exit cleanly
+const staleCloneAge = 10 * time.Minute
+
+// ProfileInfo describes a named browser profile on disk
+type ProfileInfo struct {
+	Name    string
+	Dir     string
+	Size    int64
+	ModTime time.Time
+	Lock    LockInfo
+}
+
+// LockInfo describes the Chrome instance holding a profile, if any
+type LockInfo struct {
+	Held bool
+	Host string
+	PID  int
+}
+
+// String describes the lock for humans
+func (l LockInfo) String() string {
+	switch {
+	case !l.Held:
+		return "free"
+	case l.PID != 0:
+		return fmt.Sprintf("in use by pid %d on %s", l.PID, l.Host)
+	}
+	return "in use"
+}
+
+// ProfileLock reports whether a running Chrome holds the profile in dir.
+// A lock left behind by a Chrome that is no longer running is not held.
+func ProfileLock(dir string) LockInfo {
+	return profileLock(dir)
+}
+
+// ListProfiles returns the named profiles under dir, sorted by name
+func ListProfiles(dir string) ([]ProfileInfo, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profiles directory: %w", err)
+	}
+
+	var profiles []ProfileInfo
+	for _, entry := range entries {
+		if !entry.IsDir() || entry.Name() == runsDir {
+			continue
+		}
+		p := ProfileInfo{Name: entry.Name(), Dir: filepath.Join(dir, entry.Name())}
+		p.Size, p.ModTime = dirUsage(p.Dir)
+		p.Lock = ProfileLock(p.Dir)
+		profiles = append(profiles, p)
+	}
+
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].Name < profiles[j].Name })
+	return profiles, nil
+}
+
+// ResetProfile wipes a named profile. It refuses while Chrome is using it.
+func ResetProfile(dir, name string) error {
+	if name == "" || name != filepath.Base(name) || name[0] == '.' {
+		return fmt.Errorf("invalid profile name %q", name)
+	}
+
+	path := filepath.Join(dir, name)
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("profile %q not found in %s", name, dir)
+	}
+	if lock := ProfileLock(path); lock.Held {
+		return fmt.Errorf("%w: profile %q is %s", ErrUserDataDirLocked, name, lock)
+	}
+
+	if err := os.RemoveAll(path); err != nil {
+		return fmt.Errorf("failed to remove profile %q: %w", name, err)
+	}
+	return nil
+}
+
+// cloneProfile copies the template profile into a fresh per-run directory
+// and returns its path
+func cloneProfile(template string) (string, error) {
+	if lock := ProfileLock(template); lock.Held {
+		// Copying a profile Chrome is writing to gives an inconsistent copy
+		return "", fmt.Errorf("%w: template %s is %s", ErrUserDataDirLocked, template, lock)
+	}
+
+	base := filepath.Join(filepath.Dir(template), runsDir)
+	if err := ensureDir(base); err != nil {
+		return "", err
+	}
+	pruneClones(base)
+	dir, err := os.MkdirTemp(base, filepath.Base(template)+"-")
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := os.Stat(template); os.IsNotExist(err) {
+		// Nothing to copy yet: the run starts from an empty profile
+		return dir, nil
+	}
+
+	err = filepath.WalkDir(template, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(template, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		if profileSkip[d.Name()] {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		target := filepath.Join(dir, rel)
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, 0755)
+		case d.Type().IsRegular():
+			return copyFile(path, target)
+		}
+		// Sockets, symlinks and the like are recreated by Chrome
+		return nil
+	})
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", fmt.Errorf("failed to clone profile %s: %w", template, err)
+	}
+	return dir, nil
+}
+
+// pruneClones removes copies left behind by runs that crashed
+func pruneClones(base string) {
+	entries, err := os.ReadDir(base)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		path := filepath.Join(base, entry.Name())
+		info, err := entry.Info()
+		if err != nil || time.Since(info.ModTime()) < staleCloneAge || ProfileLock(path).Held {
+			continue
+		}
+		os.RemoveAll(path)
+	}
+}
+
+// copyFile copies one regular file, keeping its permissions
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// dirUsage returns the total size and newest modification time under dir
+func dirUsage(dir string) (int64, time.Time) {
+	var size int64
+	var newest time.Time
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		if d.Type().IsRegular() {
+			size += info.Size()
+		}
+		if info.ModTime().After(newest) {
+			newest = info.ModTime()
+		}
+		return nil
+	})
+	return size, newest
+}
@@ -0,0 +1,222 @@
+package browser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/LLionNg/shopee-livestream-bot/internal/config"
+	"github.com/LLionNg/shopee-livestream-bot/internal/console"
+	"github.com/chromedp/cdproto/inspector"
+	"github.com/chromedp/chromedp"
+)
+
+// Supervisor owns the browser context and relaunches the browser when it
+// crashes or its DevTools connection drops. Code that opens tabs asks the
+// supervisor for the current browser and is told when it is replaced.
+type Supervisor struct {
+	parent      context.Context
+	cfg         *config.Config
+	maxRestarts int
+	window      time.Duration
+
+	mu         sync.Mutex
+	session    *Session
+	generation int
+	ready      chan struct{}
+	crashed    chan string
+	restarts   []time.Time
+	hooks      []func(ctx context.Context) error
+
+	failed chan struct{}
+	err    error
+}
+
+// NewSupervisor takes over a browser session created by New
+func NewSupervisor(parent context.Context, cfg *config.Config, session *Session) *Supervisor {
+	maxRestarts := 0
+	if cfg.Browser.Recovery.Enabled {
+		maxRestarts = cfg.Browser.Recovery.MaxRestarts
+	}
+
+	return &Supervisor{
+		parent:      parent,
+		cfg:         cfg,
+		maxRestarts: maxRestarts,
+		window:      cfg.Browser.Recovery.GetWindow(),
+		session:     session,
+		ready:       make(chan struct{}),
+		crashed:     make(chan string, 1),
+		failed:      make(chan struct{}),
+	}
+}
+
+// Current returns the live browser context, its generation and a channel
+// that is closed once the browser has been replaced
+func (s *Supervisor) Current() (context.Context, int, <-chan struct{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.session.Context(), s.generation, s.ready
+}
+
+// OnRestart registers a hook run against every relaunched browser, e.g. to
+// restore session cookies
+func (s *Supervisor) OnRestart(fn func(ctx context.Context) error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hooks = append(s.hooks, fn)
+}
+
+// ReportCrash asks for a restart of browser generation gen.
+// Reports about an already replaced browser are ignored.
+func (s *Supervisor) ReportCrash(gen int, reason string) {
+	s.mu.Lock()
+	current := s.generation
+	s.mu.Unlock()
+	if gen != current {
+		return
+	}
+
+	select {
+	case s.crashed <- reason:
+	default:
+	}
+}
+
+// Failed is closed when the supervisor gives up restarting the browser
+func (s *Supervisor) Failed() <-chan struct{} {
+	return s.failed
+}
+
+// Err returns why the supervisor gave up
+func (s *Supervisor) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Run watches the browser until ctx is cancelled, relaunching it after a
+// crash or disconnect. It returns an error once the restart limit is hit.
+func (s *Supervisor) Run(ctx context.Context) error {
+	for {
+		browserCtx, _, _ := s.Current()
+
+		var reason string
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-browserCtx.Done():
+			reason = "browser disconnected"
+		case reason = <-s.crashed:
+		}
+
+		// The browser also goes away when we are shutting down
+		if ctx.Err() != nil || s.parent.Err() != nil {
+			return ctx.Err()
+		}
+
+		if err := s.restart(reason); err != nil {
+			s.mu.Lock()
+			s.err = err
+			s.mu.Unlock()
+			close(s.failed)
+			return err
+		}
+	}
+}
+
+// restart relaunches the browser, respecting the restart limit
+func (s *Supervisor) restart(reason string) error {
+	console.Printf("💥 Browser lost (%s), restarting...\n", reason)
+
+	s.mu.Lock()
+	s.session.Close()
+	userAgent := s.session.userAgent
+	s.mu.Unlock()
+
+	for {
+		if err := s.allowRestart(); err != nil {
+			return err
+		}
+
+		session, err := New(s.parent, s.cfg, userAgent)
+		if err == nil {
+			s.mu.Lock()
+			s.session = session
+			hooks := append([]func(context.Context) error(nil), s.hooks...)
+			s.mu.Unlock()
+
+			for _, hook := range hooks {
+				if err := hook(session.Context()); err != nil {
+					console.Printf("⚠️  Browser restart hook failed: %v\n", err)
+				}
+			}
+
+			s.mu.Lock()
+			s.generation++
+			close(s.ready)
+			s.ready = make(chan struct{})
+			s.mu.Unlock()
+
+			console.Println("✅ Browser restarted")
+			return nil
+		}
+
+		// Retrying cannot fix a missing or incompatible browser
+		if errors.Is(err, ErrBinaryNotFound) || errors.Is(err, ErrProtocolMismatch) {
+			return err
+		}
+		console.Printf("⚠️  Browser restart failed: %v\n", err)
+
+		select {
+		case <-s.parent.Done():
+			return s.parent.Err()
+		case <-time.After(2 * time.Second):
+		}
+	}
+}
+
+// allowRestart records a restart attempt or refuses it when too many
+// happened within the configured window
+func (s *Supervisor) allowRestart() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	recent := s.restarts[:0]
+	for _, t := range s.restarts {
+		if now.Sub(t) < s.window {
+			recent = append(recent, t)
+		}
+	}
+	s.restarts = recent
+
+	if len(s.restarts) >= s.maxRestarts {
+		return fmt.Errorf("browser restarted %d times within %v, giving up", len(s.restarts), s.window)
+	}
+	s.restarts = append(s.restarts, now)
+	return nil
+}
+
+// Close shuts down the current browser
+func (s *Supervisor) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.session.Close()
+}
+
+// WatchCrash calls fn once when the tab behind ctx crashes or its
+// inspector is detached
+func WatchCrash(ctx context.Context, fn func(reason string)) {
+	var once sync.Once
+	chromedp.ListenTarget(ctx, func(ev interface{}) {
+		switch e := ev.(type) {
+		case *inspector.EventTargetCrashed:
+			once.Do(func() { go fn("tab crashed") })
+		case *inspector.EventDetached:
+			once.Do(func() { go fn("inspector detached: " + string(e.Reason)) })
+		}
+	})
+}
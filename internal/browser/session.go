@@ -0,0 +1,221 @@
+package browser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/LLionNg/shopee-livestream-bot/internal/config"
+	"github.com/LLionNg/shopee-livestream-bot/internal/console"
+	"github.com/chromedp/chromedp"
+)
+
+// Errors returned by New, usable with errors.Is
+var (
+	// ErrBinaryNotFound means no Chrome executable could be found
+	ErrBinaryNotFound = errors.New("chrome binary not found")
+	// ErrLaunchFailed means Chrome could not be started or attached to
+	ErrLaunchFailed = errors.New("browser launch failed")
+	// ErrProtocolMismatch means the browser speaks a DevTools protocol the
+	// bundled cdproto does not support
+	ErrProtocolMismatch = errors.New("devtools protocol mismatch")
+	// ErrUserDataDirLocked means another Chrome is using the user data directory
+	ErrUserDataDirLocked = errors.New("user data directory is locked")
+)
+
+// Session is a running (or attached) browser. Its root context drives the
+// first tab; further tabs are opened with chromedp.NewContext(s.Context()).
+type Session struct {
+	allocCtx    context.Context
+	allocCancel context.CancelFunc
+	ctx         context.Context
+	cancel      context.CancelFunc
+	remote      bool
+	version     *VersionInfo
+	dir         string
+	clone       bool
+	userAgent   string
+}
+
+// New launches Chrome, or attaches to browser.remote_url when set, and checks
+// that it speaks a compatible DevTools protocol. A launched Chrome presents
+// userAgent (usually the selector profile's) unless it is empty.
+func New(ctx context.Context, cfg *config.Config, userAgent string) (*Session, error) {
+	if cfg.Browser.RemoteURL != "" {
+		return newRemote(ctx, cfg)
+	}
+
+	s := &Session{dir: cfg.Browser.ProfileDir(), userAgent: userAgent}
+
+	// Run on a private copy of the profile, or make sure nobody else uses it
+	if s.dir != "" && cfg.Browser.CloneProfile {
+		dir, err := cloneProfile(s.dir)
+		if err != nil {
+			return nil, err
+		}
+		console.Printf("Using a copy of profile %s: %s\n", s.dir, dir)
+		s.dir, s.clone = dir, true
+	} else if s.dir != "" {
+		if lock := ProfileLock(s.dir); lock.Held {
+			return nil, fmt.Errorf("%w: %s is %s", ErrUserDataDirLocked, s.dir, lock)
+		}
+		// Create user data directory if it doesn't exist
+		if err := ensureDir(s.dir); err != nil {
+			return nil, fmt.Errorf("%w: failed to create user data directory: %w", ErrLaunchFailed, err)
+		}
+	}
+
+	// Find Chrome executable path (configured or OS-specific discovery)
+	chromePath, err := FindChrome(cfg.Browser.ExecPath)
+	if err != nil {
+		s.removeClone()
+		return nil, fmt.Errorf("%w: %w", ErrBinaryNotFound, err)
+	}
+	if chromePath != "" {
+		console.Printf("Found Chrome at: %s\n", chromePath)
+	}
+
+	// Build Chrome options from scratch to have full control
+	opts := []chromedp.ExecAllocatorOption{
+		chromedp.NoFirstRun,
+		chromedp.NoDefaultBrowserCheck,
+		chromedp.Flag("disable-blink-features", "AutomationControlled"),
+		chromedp.Flag("enable-automation", false),
+		chromedp.WindowSize(cfg.Browser.Viewport.Width, cfg.Browser.Viewport.Height),
+	}
+	if userAgent != "" {
+		opts = append(opts, chromedp.UserAgent(userAgent))
+	}
+
+	// Add Chrome executable path if found
+	if chromePath != "" {
+		opts = append(opts, chromedp.ExecPath(chromePath))
+	}
+
+	// Explicitly control headless mode
+	if cfg.Browser.Headless {
+		console.Println("Running in HEADLESS mode")
+		opts = append(opts, chromedp.Flag("headless", true))
+	} else {
+		console.Println("Running in VISIBLE mode (window should appear)")
+		// Explicitly disable headless to ensure window shows
+		opts = append(opts, chromedp.Flag("headless", false))
+	}
+
+	// Add user data directory for session persistence
+	if s.dir != "" {
+		opts = append(opts, chromedp.UserDataDir(s.dir))
+	}
+
+	s.allocCtx, s.allocCancel = chromedp.NewExecAllocator(ctx, opts...)
+	s.ctx, s.cancel = chromedp.NewContext(s.allocCtx, chromedp.WithLogf(func(string, ...interface{}) {}))
+
+	// Actually start the browser and navigate to a page to make window visible
+	// This ensures Chrome is launched and visible before we return
+	console.Println("Launching Chrome browser and opening window...")
+	err = chromedp.Run(s.ctx,
+		chromedp.Navigate("about:blank"),
+		chromedp.Sleep(500*time.Millisecond), // Give window time to appear
+	)
+	if err != nil {
+		s.Close()
+		switch {
+		case errors.Is(err, exec.ErrNotFound):
+			return nil, fmt.Errorf("%w: %w", ErrBinaryNotFound, err)
+		case s.dir != "" && ProfileLock(s.dir).Held:
+			return nil, fmt.Errorf("%w: %s: %w", ErrUserDataDirLocked, s.dir, err)
+		}
+		return nil, fmt.Errorf("%w: %w", ErrLaunchFailed, err)
+	}
+
+	if err := s.checkVersion(); err != nil {
+		s.Close()
+		return nil, err
+	}
+	console.Println("✅ Chrome browser window should now be visible")
+
+	return s, nil
+}
+
+// newRemote attaches to a running Chrome through its DevTools endpoint.
+// The bot only opens (and on Close closes) its own tab: chromedp never sends
+// Browser.close for a remote allocator, so a browser the bot didn't start is
+// left running when the bot detaches.
+func newRemote(ctx context.Context, cfg *config.Config) (*Session, error) {
+	console.Printf("Attaching to remote Chrome at %s\n", cfg.Browser.RemoteURL)
+
+	s := &Session{remote: true}
+	s.allocCtx, s.allocCancel = chromedp.NewRemoteAllocator(ctx, cfg.Browser.RemoteURL)
+	s.ctx, s.cancel = chromedp.NewContext(s.allocCtx, chromedp.WithLogf(func(string, ...interface{}) {}))
+
+	if err := chromedp.Run(s.ctx, chromedp.Navigate("about:blank")); err != nil {
+		s.Close()
+		return nil, fmt.Errorf("%w: attaching to %s: %w", ErrLaunchFailed, cfg.Browser.RemoteURL, err)
+	}
+
+	if err := s.checkVersion(); err != nil {
+		s.Close()
+		return nil, err
+	}
+	console.Println("✅ Attached to remote Chrome (a new tab was opened for the bot)")
+
+	return s, nil
+}
+
+// checkVersion records the browser version and rejects incompatible browsers
+func (s *Session) checkVersion() error {
+	v, err := GetVersion(s.ctx)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrLaunchFailed, err)
+	}
+	if err := v.Compatible(); err != nil {
+		return fmt.Errorf("%w: %w", ErrProtocolMismatch, err)
+	}
+	s.version = v
+	return nil
+}
+
+// Context returns the root browser context (the bot's first tab)
+func (s *Session) Context() context.Context {
+	return s.ctx
+}
+
+// AllocatorContext returns the allocator context the browser was started from
+func (s *Session) AllocatorContext() context.Context {
+	return s.allocCtx
+}
+
+// Version returns what the browser reported about itself
+func (s *Session) Version() *VersionInfo {
+	return s.version
+}
+
+// Remote reports whether the session is attached to a browser the bot did
+// not start
+func (s *Session) Remote() bool {
+	return s.remote
+}
+
+// Dir returns the user data directory Chrome runs on ("" for a throwaway one)
+func (s *Session) Dir() string {
+	return s.dir
+}
+
+// Close closes the bot's tab and, for a launched browser, Chrome itself.
+// A per-run profile copy is deleted once Chrome has exited.
+func (s *Session) Close() {
+	s.cancel()
+	s.allocCancel()
+	s.removeClone()
+}
+
+// removeClone deletes the per-run profile copy, if any
+func (s *Session) removeClone() {
+	if s.clone {
+		os.RemoveAll(s.dir)
+		s.clone = false
+	}
+}
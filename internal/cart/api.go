@@ -0,0 +1,198 @@
+package cart
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/LLionNg/shopee-livestream-bot/internal/product"
+	"github.com/LLionNg/shopee-livestream-bot/internal/region"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+)
+
+// Update actions as sent by Shopee's web client
+const (
+	actionChangeQuantity = 1
+	actionDelete         = 2
+)
+
+// apiItem is the part of a cart item in the cart API the bot reads
+type apiItem struct {
+	ItemID    int64  `json:"itemid"`
+	ShopID    int64  `json:"shopid"`
+	ModelID   int64  `json:"modelid"`
+	Name      string `json:"name"`
+	ModelName string `json:"model_name"`
+	Quantity  int    `json:"quantity"`
+	Price     int64  `json:"price"`
+}
+
+// apiShopOrder groups a shop's items in the cart API
+type apiShopOrder struct {
+	Shop struct {
+		ShopID   int64  `json:"shopid"`
+		ShopName string `json:"shop_name"`
+	} `json:"shop"`
+	Items []apiItem `json:"items"`
+}
+
+// ParseAPI reads the items from a cart API response. Shop orders are found
+// wherever they sit in the response, as objects with a "shop" and "items".
+func ParseAPI(body []byte, c region.Currency) ([]Item, error) {
+	var root interface{}
+	if err := json.Unmarshal(body, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse cart response: %w", err)
+	}
+
+	var items []Item
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch node := v.(type) {
+		case map[string]interface{}:
+			_, hasShop := node["shop"].(map[string]interface{})
+			_, hasItems := node["items"].([]interface{})
+			if hasShop && hasItems {
+				items = append(items, shopItems(node, c)...)
+				return
+			}
+			for _, child := range node {
+				walk(child)
+			}
+		case []interface{}:
+			for _, child := range node {
+				walk(child)
+			}
+		}
+	}
+	walk(root)
+
+	for i := range items {
+		items[i].row = i
+	}
+	return items, nil
+}
+
+func shopItems(node map[string]interface{}, c region.Currency) []Item {
+	raw, err := json.Marshal(node)
+	if err != nil {
+		return nil
+	}
+	var order apiShopOrder
+	if err := json.Unmarshal(raw, &order); err != nil {
+		return nil
+	}
+
+	var items []Item
+	for _, it := range order.Items {
+		if it.ItemID == 0 {
+			continue
+		}
+		shopID := it.ShopID
+		if shopID == 0 {
+			shopID = order.Shop.ShopID
+		}
+		items = append(items, Item{
+			ShopID:   shopID,
+			ItemID:   it.ItemID,
+			ModelID:  it.ModelID,
+			Shop:     order.Shop.ShopName,
+			Name:     it.Name,
+			Variant:  it.ModelName,
+			Quantity: it.Quantity,
+			Price:    product.APIMoney(it.Price, c),
+		})
+	}
+	return items
+}
+
+// itemBrief is one item of a cart update request
+type itemBrief struct {
+	ItemID      int64 `json:"itemid"`
+	ModelID     int64 `json:"modelid"`
+	Quantity    int   `json:"quantity"`
+	OldModelID  int64 `json:"old_modelid"`
+	OldQuantity int   `json:"old_quantity"`
+}
+
+// shopUpdate groups a shop's items in a cart update request
+type shopUpdate struct {
+	ShopID     int64       `json:"shopid"`
+	ItemBriefs []itemBrief `json:"item_briefs"`
+}
+
+// updateRequest builds a cart update body for items, using quantity(item)
+// as each item's new quantity
+func updateRequest(action int, items []Item, quantity func(Item) int) map[string]interface{} {
+	var shops []shopUpdate
+	index := map[int64]int{}
+	for _, item := range items {
+		i, ok := index[item.ShopID]
+		if !ok {
+			i = len(shops)
+			index[item.ShopID] = i
+			shops = append(shops, shopUpdate{ShopID: item.ShopID})
+		}
+		shops[i].ItemBriefs = append(shops[i].ItemBriefs, itemBrief{
+			ItemID:      item.ItemID,
+			ModelID:     item.ModelID,
+			Quantity:    quantity(item),
+			OldModelID:  item.ModelID,
+			OldQuantity: item.Quantity,
+		})
+	}
+	return map[string]interface{}{
+		"action_type":            action,
+		"updated_shop_order_ids": shops,
+	}
+}
+
+// postJS posts a JSON body from the page so the session cookies and CSRF
+// token go along; it resolves to the response text, or null on failure
+const postJS = `(async () => {
+	const csrf = (document.cookie.match(/(?:^|; )csrftoken=([^;]*)/) || [])[1] || '';
+	try {
+		const r = await fetch(%s, {
+			method: 'POST',
+			credentials: 'include',
+			headers: {'Content-Type': 'application/json', 'Accept': 'application/json', 'X-CSRFToken': csrf, 'X-Requested-With': 'XMLHttpRequest'},
+			body: JSON.stringify(%s),
+		});
+		return await r.text();
+	} catch (e) {
+		return null;
+	}
+})()`
+
+// post sends body to the cart update endpoint from the Shopee page in ctx
+func (m *Manager) post(ctx context.Context, body interface{}) error {
+	url, _ := json.Marshal(m.cfg.Cart.UpdateURL(m.cfg.Shopee.APIURL))
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	var text *string
+	err = chromedp.Run(ctx, chromedp.Evaluate(fmt.Sprintf(postJS, url, payload), &text,
+		func(p *runtime.EvaluateParams) *runtime.EvaluateParams {
+			return p.WithAwaitPromise(true)
+		}))
+	if err != nil {
+		return fmt.Errorf("cart update failed: %w", err)
+	}
+	if text == nil {
+		return fmt.Errorf("cart update endpoint unreachable")
+	}
+
+	var result struct {
+		Error    int    `json:"error"`
+		ErrorMsg string `json:"error_msg"`
+	}
+	if err := json.Unmarshal([]byte(*text), &result); err != nil {
+		return fmt.Errorf("unexpected cart update response: %.100s", *text)
+	}
+	if result.Error != 0 {
+		return fmt.Errorf("cart update rejected: error %d %s", result.Error, result.ErrorMsg)
+	}
+	return nil
+}
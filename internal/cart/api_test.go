@@ -0,0 +1,62 @@
+package cart
+
+import (
+	"testing"
+
+	"github.com/LLionNg/shopee-livestream-bot/internal/region"
+)
+
+var thb = region.Currency{Code: "THB", Symbol: "฿", MinorDigits: 2, Thousands: ",", Decimal: "."}
+
+func TestParseAPI(t *testing.T) {
+	body := []byte(`{
+		"error": 0,
+		"data": {
+			"shop_orders": [
+				{
+					"shop": {"shopid": 11, "shop_name": "Mug Shop"},
+					"items": [
+						{"itemid": 22, "modelid": 1, "name": "Test Mug", "model_name": "Red", "quantity": 2, "price": 15000000},
+						{"itemid": 22, "modelid": 2, "name": "Test Mug", "model_name": "Blue", "quantity": 1, "price": 15000000},
+						{"itemid": 0, "name": "Bundle deal banner"}
+					]
+				},
+				{
+					"shop": {"shopid": 33, "shop_name": "Tea Shop"},
+					"items": [{"itemid": 44, "shopid": 34, "name": "Green Tea", "quantity": 1, "price": 9900000}]
+				}
+			]
+		}
+	}`)
+
+	items, err := ParseAPI(body, thb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		key, shop, variant string
+		quantity           int
+		price              int64
+	}{
+		{"11.22.1", "Mug Shop", "Red", 2, 15000},
+		{"11.22.2", "Mug Shop", "Blue", 1, 15000},
+		{"34.44.0", "Tea Shop", "", 1, 9900}, // the item's own shop ID wins
+	}
+	if len(items) != len(want) {
+		t.Fatalf("got %d items, want %d: %+v", len(items), len(want), items)
+	}
+	for i, w := range want {
+		it := items[i]
+		if it.Key() != w.key || it.Shop != w.shop || it.Variant != w.variant || it.Quantity != w.quantity || it.Price.Amount != w.price {
+			t.Errorf("item %d = %s %q %q x%d %d, want %s %q %q x%d %d",
+				i, it.Key(), it.Shop, it.Variant, it.Quantity, it.Price.Amount, w.key, w.shop, w.variant, w.quantity, w.price)
+		}
+	}
+
+	if items, err := ParseAPI([]byte(`{"error": 0, "data": {}}`), thb); err != nil || len(items) != 0 {
+		t.Errorf("empty cart = %+v, %v", items, err)
+	}
+	if _, err := ParseAPI([]byte(`<html>`), thb); err == nil {
+		t.Error("ParseAPI accepted HTML")
+	}
+}
@@ -0,0 +1,139 @@
+package cart
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/LLionNg/shopee-livestream-bot/internal/product"
+)
+
+// Cart sources
+const (
+	SourceAPI = "api"
+	SourceDOM = "page"
+)
+
+// Item is one line of the cart: a product variant and its quantity
+type Item struct {
+	ShopID        int64         `json:"shop_id"`
+	ItemID        int64         `json:"item_id"`
+	ModelID       int64         `json:"model_id"`
+	Shop          string        `json:"shop"`
+	Name          string        `json:"name"`
+	Variant       string        `json:"variant"`
+	Quantity      int           `json:"quantity"`
+	Price         product.Money `json:"price"` // unit price
+	ReservedUntil time.Time     `json:"reserved_until,omitempty"`
+
+	// row is the item's position on the cart page, used when the API
+	// can't be reached
+	row int
+}
+
+// Subtotal returns the unit price times the quantity
+func (i Item) Subtotal() product.Money {
+	return product.Money{Amount: i.Price.Amount * int64(i.Quantity), Currency: i.Price.Currency}
+}
+
+// Reserved reports whether the item is held for the buyer
+func (i Item) Reserved() bool {
+	return !i.ReservedUntil.IsZero()
+}
+
+// Remaining formats the time left on the reservation, e.g. "4m05s"
+func (i Item) Remaining(now time.Time) string {
+	if !i.Reserved() {
+		return "-"
+	}
+	left := i.ReservedUntil.Sub(now).Round(time.Second)
+	if left <= 0 {
+		return "released"
+	}
+	return fmt.Sprintf("%dm%02ds", int(left.Minutes()), int(left.Seconds())%60)
+}
+
+// Key identifies the item and variant, e.g. "123.456.789"
+func (i Item) Key() string {
+	if i.ItemID == 0 {
+		return fmt.Sprintf("#%d", i.row+1)
+	}
+	return fmt.Sprintf("%d.%d.%d", i.ShopID, i.ItemID, i.ModelID)
+}
+
+// Cart is the list of items in the logged-in user's cart
+type Cart struct {
+	Items     []Item    `json:"items"`
+	Source    string    `json:"source"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// ShopTotal is the subtotal of one shop's items
+type ShopTotal struct {
+	Shop     string
+	Quantity int
+	Total    product.Money
+}
+
+// Quantity returns the number of units across all items
+func (c *Cart) Quantity() int {
+	n := 0
+	for _, item := range c.Items {
+		n += item.Quantity
+	}
+	return n
+}
+
+// Total returns the sum of all subtotals
+func (c *Cart) Total() product.Money {
+	var total product.Money
+	for _, item := range c.Items {
+		sub := item.Subtotal()
+		total.Amount += sub.Amount
+		total.Currency = sub.Currency
+	}
+	return total
+}
+
+// ShopTotals returns the subtotals per shop in cart order
+func (c *Cart) ShopTotals() []ShopTotal {
+	var totals []ShopTotal
+	index := map[string]int{}
+	for _, item := range c.Items {
+		i, ok := index[item.Shop]
+		if !ok {
+			i = len(totals)
+			index[item.Shop] = i
+			totals = append(totals, ShopTotal{Shop: item.Shop})
+		}
+		sub := item.Subtotal()
+		totals[i].Quantity += item.Quantity
+		totals[i].Total.Amount += sub.Amount
+		totals[i].Total.Currency = sub.Currency
+	}
+	return totals
+}
+
+// Find looks up an item by its position in the list (1-based), its key or
+// its item ID. An item ID matching several variants is ambiguous.
+func (c *Cart) Find(ref string) (Item, error) {
+	ref = strings.TrimPrefix(strings.TrimSpace(ref), "#")
+	if n, err := strconv.Atoi(ref); err == nil && n >= 1 && n <= len(c.Items) {
+		return c.Items[n-1], nil
+	}
+
+	var matches []Item
+	for _, item := range c.Items {
+		if item.Key() == ref || strconv.FormatInt(item.ItemID, 10) == ref {
+			matches = append(matches, item)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return Item{}, fmt.Errorf("no cart item %q", ref)
+	case 1:
+		return matches[0], nil
+	}
+	return Item{}, fmt.Errorf("cart item %q matches %d variants, use the list position or key", ref, len(matches))
+}
@@ -0,0 +1,284 @@
+package cart
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/LLionNg/shopee-livestream-bot/internal/browser"
+	"github.com/LLionNg/shopee-livestream-bot/internal/config"
+	"github.com/LLionNg/shopee-livestream-bot/internal/console"
+	"github.com/LLionNg/shopee-livestream-bot/internal/selectors"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+// apiWait is how long the cart page gets to load its items from the API
+const apiWait = 10 * time.Second
+
+// Manager reads and edits the cart of the browser's logged-in user. Every
+// method opens the cart page in a new tab of the browser behind ctx.
+type Manager struct {
+	cfg *config.Config
+	sel *selectors.Store
+}
+
+// NewManager creates a new cart manager
+func NewManager(cfg *config.Config, sel *selectors.Store) *Manager {
+	return &Manager{cfg: cfg, sel: sel}
+}
+
+// List returns the items in the cart
+func (m *Manager) List(ctx context.Context) (*Cart, error) {
+	tab, cancel := chromedp.NewContext(ctx)
+	defer cancel()
+	return m.open(tab)
+}
+
+// Remove deletes items from the cart. Items must come from a List call.
+func (m *Manager) Remove(ctx context.Context, items ...Item) error {
+	if len(items) == 0 {
+		return nil
+	}
+	tab, cancel := chromedp.NewContext(ctx)
+	defer cancel()
+	c, err := m.open(tab)
+	if err != nil {
+		return err
+	}
+
+	if c.Source == SourceAPI && hasIDs(items) {
+		err := m.post(tab, updateRequest(actionDelete, items, func(Item) int { return 0 }))
+		if err == nil {
+			return nil
+		}
+		console.Printf("⚠️  %v, removing on the cart page instead\n", err)
+	}
+
+	rows := make([]int, 0, len(items))
+	for _, item := range items {
+		row, err := locate(c, item)
+		if err != nil {
+			return err
+		}
+		rows = append(rows, row)
+	}
+
+	// Remove from the bottom so earlier row positions stay valid
+	sort.Sort(sort.Reverse(sort.IntSlice(rows)))
+	for _, row := range rows {
+		if err := m.removeRow(tab, row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetQuantity changes an item's quantity; zero removes it
+func (m *Manager) SetQuantity(ctx context.Context, item Item, quantity int) error {
+	if quantity < 0 {
+		return fmt.Errorf("quantity must not be negative")
+	}
+	if quantity == 0 {
+		return m.Remove(ctx, item)
+	}
+
+	tab, cancel := chromedp.NewContext(ctx)
+	defer cancel()
+	c, err := m.open(tab)
+	if err != nil {
+		return err
+	}
+
+	if c.Source == SourceAPI && hasIDs([]Item{item}) {
+		err := m.post(tab, updateRequest(actionChangeQuantity, []Item{item}, func(Item) int { return quantity }))
+		if err == nil {
+			return nil
+		}
+		console.Printf("⚠️  %v, changing the quantity on the cart page instead\n", err)
+	}
+
+	row, err := locate(c, item)
+	if err != nil {
+		return err
+	}
+	return m.setRowQuantity(tab, row, quantity)
+}
+
+// Clear removes every item from the cart
+func (m *Manager) Clear(ctx context.Context) error {
+	tab, cancel := chromedp.NewContext(ctx)
+	defer cancel()
+	c, err := m.open(tab)
+	if err != nil {
+		return err
+	}
+	if len(c.Items) == 0 {
+		return nil
+	}
+
+	if c.Source == SourceAPI && hasIDs(c.Items) {
+		err := m.post(tab, updateRequest(actionDelete, c.Items, func(Item) int { return 0 }))
+		if err == nil {
+			return nil
+		}
+		console.Printf("⚠️  %v, clearing on the cart page instead\n", err)
+	}
+	return m.clearPage(tab)
+}
+
+// SelectOnly opens the cart page in the tab behind ctx and ticks only the
+// item matching r, ready for checkout. It returns the cart item.
+func (m *Manager) SelectOnly(ctx context.Context, r Reservation) (Item, error) {
+	c, err := m.open(ctx)
+	if err != nil {
+		return Item{}, err
+	}
+	row := -1
+	for i, item := range c.Items {
+		if r.Matches(item) {
+			row = i
+			break
+		}
+	}
+	if row < 0 {
+		return Item{}, fmt.Errorf("%s is not in the cart", r.Name)
+	}
+	if err := m.selectRow(ctx, row); err != nil {
+		return Item{}, err
+	}
+	return c.Items[row], nil
+}
+
+// open loads the cart page in tab and reads the items, preferring the cart
+// API response the page fetches over scraping the rows
+func (m *Manager) open(tab context.Context) (*Cart, error) {
+	getURL := m.cfg.Cart.GetURL(m.cfg.Shopee.APIURL)
+	bodies := make(chan []byte, 1)
+
+	chromedp.ListenTarget(tab, func(ev interface{}) {
+		e, ok := ev.(*network.EventResponseReceived)
+		if !ok || !strings.HasPrefix(e.Response.URL, getURL) {
+			return
+		}
+
+		// Fetching the body must not block the event loop
+		go func(id network.RequestID) {
+			var body []byte
+			err := chromedp.Run(tab, chromedp.ActionFunc(func(ctx context.Context) error {
+				var err error
+				body, err = network.GetResponseBody(id).Do(ctx)
+				return err
+			}))
+			if err != nil {
+				return
+			}
+			select {
+			case bodies <- body:
+			default:
+			}
+		}(e.RequestID)
+	})
+
+	if err := browser.NavigateWithRetry(tab, m.cfg.Cart.PageURL, 3); err != nil {
+		return nil, fmt.Errorf("failed to open cart: %w", err)
+	}
+
+	c := &Cart{FetchedAt: time.Now()}
+	select {
+	case body := <-bodies:
+		items, err := ParseAPI(body, m.cfg.Shopee.GetRegion().Currency)
+		if err == nil {
+			// Only the page shows reservation clocks
+			chromedp.Run(tab, chromedp.Sleep(time.Second))
+			if rows, err := m.readPage(tab); err == nil {
+				mergeReservations(items, rows)
+			}
+			c.Items, c.Source = items, SourceAPI
+			return c, nil
+		}
+		console.Printf("⚠️  %v, reading the cart page instead\n", err)
+	case <-time.After(apiWait):
+	case <-tab.Done():
+		return nil, tab.Err()
+	}
+
+	items, err := m.readPage(tab)
+	if err != nil {
+		return nil, err
+	}
+	c.Items, c.Source = items, SourceDOM
+	return c, nil
+}
+
+// mergeReservations copies reservation deadlines from scraped rows onto
+// API items. A row only matches the item with its shop and item ID (and
+// model ID, when the row has one); a row that could be several variants of
+// the item is left out rather than guessed.
+func mergeReservations(items, rows []Item) {
+	for i := range items {
+		var match *Item
+		for j := range rows {
+			if sameItem(rows[j], items[i]) {
+				if match != nil {
+					match = nil
+					break
+				}
+				match = &rows[j]
+			}
+		}
+		if match == nil || !match.Reserved() {
+			continue
+		}
+		if match.ModelID == 0 && variants(items, items[i]) > 1 {
+			continue
+		}
+		items[i].ReservedUntil = match.ReservedUntil
+	}
+}
+
+// sameItem reports whether the scraped row can be the API item
+func sameItem(row, item Item) bool {
+	return row.ItemID != 0 && row.ItemID == item.ItemID &&
+		(row.ShopID == 0 || row.ShopID == item.ShopID) &&
+		(row.ModelID == 0 || row.ModelID == item.ModelID)
+}
+
+// variants counts the items in the cart that are variants of item
+func variants(items []Item, item Item) int {
+	n := 0
+	for _, other := range items {
+		if other.ShopID == item.ShopID && other.ItemID == item.ItemID {
+			n++
+		}
+	}
+	return n
+}
+
+// locate finds an item's row on the freshly opened cart page
+func locate(c *Cart, item Item) (int, error) {
+	if item.ItemID != 0 {
+		for i, other := range c.Items {
+			if other.ItemID == item.ItemID && (item.ModelID == 0 || other.ModelID == item.ModelID) {
+				return i, nil
+			}
+		}
+		return 0, fmt.Errorf("%s is no longer in the cart", item.Key())
+	}
+	if item.row >= len(c.Items) {
+		return 0, fmt.Errorf("cart row %d no longer exists", item.row+1)
+	}
+	return item.row, nil
+}
+
+// hasIDs reports whether every item can be addressed through the API
+func hasIDs(items []Item) bool {
+	for _, item := range items {
+		if item.ItemID == 0 || item.ShopID == 0 {
+			return false
+		}
+	}
+	return true
+}
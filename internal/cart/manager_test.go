@@ -0,0 +1,35 @@
+package cart
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMergeReservations(t *testing.T) {
+	at := time.Date(2024, 5, 18, 20, 15, 0, 0, time.UTC)
+	items := []Item{
+		{ShopID: 11, ItemID: 22, ModelID: 1}, // two variants of one item
+		{ShopID: 11, ItemID: 22, ModelID: 2},
+		{ShopID: 33, ItemID: 44},
+		{ShopID: 55, ItemID: 66},
+		{ShopID: 77, ItemID: 88},
+	}
+	rows := []Item{
+		{ShopID: 11, ItemID: 22, ReservedUntil: at},  // can't tell which variant
+		{ShopID: 33, ItemID: 44, ReservedUntil: at},  // the only variant
+		{ItemID: 0, ReservedUntil: at},               // no ID: never matched by position
+		{ShopID: 99, ItemID: 66, ReservedUntil: at},  // another shop's item
+		{ShopID: 77, ItemID: 88},                     // not reserved
+		{ShopID: 12, ItemID: 999, ReservedUntil: at}, // not in the API cart
+	}
+
+	mergeReservations(items, rows)
+	for i, want := range []bool{false, false, true, false, false} {
+		if got := items[i].Reserved(); got != want {
+			t.Errorf("item %s reserved = %v, want %v", items[i].Key(), got, want)
+		}
+	}
+	if !items[2].ReservedUntil.Equal(at) {
+		t.Errorf("item %s reserved until %s, want %s", items[2].Key(), items[2].ReservedUntil, at)
+	}
+}
@@ -0,0 +1,234 @@
+package cart
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/LLionNg/shopee-livestream-bot/internal/product"
+	"github.com/LLionNg/shopee-livestream-bot/internal/region"
+	"github.com/LLionNg/shopee-livestream-bot/internal/selectors"
+	"github.com/chromedp/chromedp"
+)
+
+// countdownPattern matches a reservation clock such as "14:59" or "1:02:03",
+// also when its digits sit in separate boxes ("00 : 14 : 59")
+var countdownPattern = regexp.MustCompile(`(\d{1,2})\s*:\s*(\d{2})(?:\s*:\s*(\d{2}))?`)
+
+// rowsJS defines pick() and rows() for the cart page scripts below
+const rowsJS = `
+	const pick = (root, list) => {
+		for (const s of list) {
+			const el = root.querySelector(s);
+			if (el) return el;
+		}
+		return null;
+	};
+	const text = (root, list) => {
+		const el = pick(root, list);
+		return el ? (el.innerText || el.value || '').trim() : '';
+	};
+	const rows = () => {
+		for (const s of %s) {
+			const found = Array.from(document.querySelectorAll(s));
+			if (found.length) return found;
+		}
+		return [];
+	};`
+
+// pageRow is what the cart page exposes about one row
+type pageRow struct {
+	Name     string `json:"name"`
+	Variant  string `json:"variant"`
+	Price    string `json:"price"`
+	Quantity string `json:"quantity"`
+	Reserved string `json:"reserved"`
+	Shop     string `json:"shop"`
+	URL      string `json:"url"`
+}
+
+// rowsScript prefixes script with the row helpers
+func (m *Manager) rowsScript(script string, args ...interface{}) string {
+	rows, _ := json.Marshal(m.sel.Get(selectors.CartItem))
+	return fmt.Sprintf("(() => {"+rowsJS+script+"})()", append([]interface{}{rows}, args...)...)
+}
+
+// readPage scrapes the cart rows from the cart page in ctx
+func (m *Manager) readPage(ctx context.Context) ([]Item, error) {
+	list := func(name string) []byte {
+		b, _ := json.Marshal(m.sel.Get(name))
+		return b
+	}
+	script := m.rowsScript(`
+	return rows().map(row => {
+		let shop = '';
+		for (let p = row.parentElement; p && !shop; p = p.parentElement) shop = text(p, %s);
+		const qty = pick(row, %s);
+		const link = row.querySelector('a[href*="-i."], a[href*="/product/"]');
+		return {
+			name: text(row, %s),
+			variant: text(row, %s),
+			price: text(row, %s),
+			quantity: qty ? (qty.value || qty.innerText || '').trim() : '',
+			reserved: text(row, %s),
+			shop: shop,
+			url: link ? link.href : '',
+		};
+	});`,
+		list(selectors.CartShopName), list(selectors.CartItemQuantity), list(selectors.CartItemName),
+		list(selectors.CartItemVariant), list(selectors.CartItemPrice), list(selectors.CartItemReserved))
+
+	var rows []pageRow
+	if err := chromedp.Run(ctx, chromedp.Evaluate(script, &rows)); err != nil {
+		return nil, fmt.Errorf("failed to read cart page: %w", err)
+	}
+	return parseRows(rows, m.cfg.Shopee.GetRegion().Currency, time.Now()), nil
+}
+
+// parseRows turns scraped rows into items; unreadable fields stay empty
+func parseRows(rows []pageRow, c region.Currency, now time.Time) []Item {
+	items := make([]Item, 0, len(rows))
+	for i, r := range rows {
+		item := Item{
+			Shop:    r.Shop,
+			Name:    r.Name,
+			Variant: r.Variant,
+			row:     i,
+		}
+		if shopID, itemID, ok := product.ParseItemURL(r.URL); ok {
+			item.ShopID, item.ItemID = shopID, itemID
+		}
+		if price, _, err := product.ParsePriceRange(r.Price, c); err == nil {
+			item.Price = price
+		}
+		if q, err := strconv.Atoi(strings.TrimSpace(r.Quantity)); err == nil {
+			item.Quantity = q
+		}
+		if left, ok := ParseCountdown(r.Reserved); ok {
+			item.ReservedUntil = now.Add(left)
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// ParseCountdown reads the time left from a reservation clock such as
+// "Reserved 14:59"
+func ParseCountdown(text string) (time.Duration, bool) {
+	match := countdownPattern.FindStringSubmatch(text)
+	if match == nil {
+		return 0, false
+	}
+	a, _ := strconv.Atoi(match[1])
+	b, _ := strconv.Atoi(match[2])
+	if match[3] == "" {
+		return time.Duration(a)*time.Minute + time.Duration(b)*time.Second, true
+	}
+	c, _ := strconv.Atoi(match[3])
+	return time.Duration(a)*time.Hour + time.Duration(b)*time.Minute + time.Duration(c)*time.Second, true
+}
+
+// removeRow clicks the remove button of a row and confirms the dialog
+func (m *Manager) removeRow(ctx context.Context, row int) error {
+	remove, _ := json.Marshal(m.sel.Get(selectors.CartItemRemove))
+	script := m.rowsScript(`
+	const row = rows()[%d];
+	const btn = row && pick(row, %s);
+	if (!btn) return false;
+	btn.click();
+	return true;`, row, remove)
+
+	var clicked bool
+	if err := chromedp.Run(ctx, chromedp.Evaluate(script, &clicked)); err != nil {
+		return fmt.Errorf("failed to remove cart row %d: %w", row+1, err)
+	}
+	if !clicked {
+		return fmt.Errorf("cart row %d has no remove button", row+1)
+	}
+	return m.confirm(ctx)
+}
+
+// setRowQuantity types a new quantity into a row's quantity field
+func (m *Manager) setRowQuantity(ctx context.Context, row, quantity int) error {
+	input, _ := json.Marshal(m.sel.Get(selectors.CartItemQuantity))
+	script := m.rowsScript(`
+	const row = rows()[%d];
+	const el = row && pick(row, %s);
+	if (!el) return false;
+	const setter = Object.getOwnPropertyDescriptor(HTMLInputElement.prototype, 'value').set;
+	el.focus();
+	setter.call(el, '%d');
+	el.dispatchEvent(new Event('input', {bubbles: true}));
+	el.dispatchEvent(new Event('change', {bubbles: true}));
+	el.blur();
+	return true;`, row, input, quantity)
+
+	var done bool
+	if err := chromedp.Run(ctx, chromedp.Evaluate(script, &done), chromedp.Sleep(time.Second)); err != nil {
+		return fmt.Errorf("failed to change quantity of cart row %d: %w", row+1, err)
+	}
+	if !done {
+		return fmt.Errorf("cart row %d has no quantity field", row+1)
+	}
+	return nil
+}
+
+// selectRow ticks the checkbox of one row and unticks all others
+func (m *Manager) selectRow(ctx context.Context, row int) error {
+	box, _ := json.Marshal(m.sel.Get(selectors.CartItemSelect))
+	script := m.rowsScript(`
+	const all = rows();
+	if (!all[%d]) return false;
+	let found = false;
+	all.forEach((r, i) => {
+		const el = pick(r, %s);
+		if (!el) return;
+		const checked = el.checked !== undefined ? el.checked : el.getAttribute('aria-checked') === 'true';
+		if (checked !== (i === %d)) el.click();
+		if (i === %d) found = true;
+	});
+	return found;`, row, box, row, row)
+
+	var found bool
+	if err := chromedp.Run(ctx, chromedp.Evaluate(script, &found), chromedp.Sleep(500*time.Millisecond)); err != nil {
+		return fmt.Errorf("failed to select cart row %d: %w", row+1, err)
+	}
+	if !found {
+		return fmt.Errorf("cart row %d has no checkbox", row+1)
+	}
+	return nil
+}
+
+// clearPage selects every row, deletes and confirms
+func (m *Manager) clearPage(ctx context.Context) error {
+	for _, name := range []string{selectors.CartSelectAll, selectors.CartDelete} {
+		selector, err := m.sel.Find(ctx, name)
+		if err != nil {
+			return fmt.Errorf("failed to clear cart: %w", err)
+		}
+		if err := chromedp.Run(ctx,
+			chromedp.Click(selector, chromedp.ByQuery),
+			chromedp.Sleep(500*time.Millisecond),
+		); err != nil {
+			return fmt.Errorf("failed to clear cart: %w", err)
+		}
+	}
+	return m.confirm(ctx)
+}
+
+// confirm accepts the confirmation dialog if one opened
+func (m *Manager) confirm(ctx context.Context) error {
+	chromedp.Run(ctx, chromedp.Sleep(500*time.Millisecond))
+	selector, err := m.sel.Find(ctx, selectors.CartConfirm)
+	if err != nil {
+		return nil
+	}
+	return chromedp.Run(ctx,
+		chromedp.Click(selector, chromedp.ByQuery),
+		chromedp.Sleep(500*time.Millisecond),
+	)
+}
@@ -0,0 +1,60 @@
+package cart
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRows(t *testing.T) {
+	now := time.Date(2024, 5, 18, 20, 0, 0, 0, time.UTC)
+	rows := []pageRow{
+		{
+			Name: "Test Mug", Variant: "Variation: Red", Price: "฿150", Quantity: " 2 ",
+			Reserved: "Reserved for 14:59", Shop: "Mug Shop", URL: "https://shopee.co.th/Test-Mug-i.11.22",
+		},
+		{Name: "Mystery box", Price: "฿1,290 - ฿1,590", Quantity: "one"},
+	}
+
+	items := parseRows(rows, thb, now)
+	if len(items) != 2 {
+		t.Fatalf("got %d items, want 2", len(items))
+	}
+	mug := items[0]
+	if mug.Key() != "11.22.0" || mug.Shop != "Mug Shop" || mug.Variant != "Variation: Red" ||
+		mug.Quantity != 2 || mug.Price.Amount != 15000 {
+		t.Errorf("mug = %+v", mug)
+	}
+	if want := now.Add(14*time.Minute + 59*time.Second); !mug.ReservedUntil.Equal(want) {
+		t.Errorf("mug reserved until %s, want %s", mug.ReservedUntil, want)
+	}
+
+	box := items[1]
+	if box.Key() != "#2" || box.Quantity != 0 || box.Price.Amount != 129000 || box.Reserved() {
+		t.Errorf("box = %+v, want row #2 with the lowest price and no quantity or reservation", box)
+	}
+}
+
+func TestParseCountdown(t *testing.T) {
+	tests := []struct {
+		text string
+		want time.Duration
+		ok   bool
+	}{
+		{"14:59", 14*time.Minute + 59*time.Second, true},
+		{"Reserved 04:05", 4*time.Minute + 5*time.Second, true},
+		{"1:02:03", time.Hour + 2*time.Minute + 3*time.Second, true},
+		{"00 : 14 : 59", 14*time.Minute + 59*time.Second, true}, // digits in boxes
+		{"00\n:\n09\n:\n30", 9*time.Minute + 30*time.Second, true},
+		{"สินค้าถูกจองไว้ 09:30 นาที", 9*time.Minute + 30*time.Second, true},
+		{"Giữ hàng trong 1:02:03", time.Hour + 2*time.Minute + 3*time.Second, true},
+		{"Reservado por 05:00", 5 * time.Minute, true},
+		{"", 0, false},
+		{"Reserved", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseCountdown(tt.text)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ParseCountdown(%q) = %s, %v; want %s, %v", tt.text, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,276 @@
+package cart
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/LLionNg/shopee-livestream-bot/internal/console"
+	"github.com/LLionNg/shopee-livestream-bot/internal/notify"
+)
+
+// Where a reservation deadline came from
+const (
+	DeadlinePage   = "page"
+	DeadlineConfig = "config"
+)
+
+// reminderTick is how often the tracker checks the deadlines
+const reminderTick = 10 * time.Second
+
+// Reservation is an item added to the cart during a stream, held for the
+// buyer until a deadline
+type Reservation struct {
+	ShopID   int64     `json:"shop_id,omitempty"`
+	ItemID   int64     `json:"item_id,omitempty"`
+	Name     string    `json:"name"`
+	StreamID int       `json:"stream_id"`
+	AddedAt  time.Time `json:"added_at"`
+	Until    time.Time `json:"until"`
+	Source   string    `json:"source"`
+	// Reminded counts the reminders already sent
+	Reminded int `json:"reminded"`
+}
+
+// Key identifies the reserved product
+func (r *Reservation) Key() string {
+	if r.ItemID != 0 {
+		return fmt.Sprintf("%d.%d", r.ShopID, r.ItemID)
+	}
+	return r.Name
+}
+
+// Matches reports whether a cart item is the reserved product
+func (r *Reservation) Matches(item Item) bool {
+	if r.ItemID != 0 && item.ItemID != 0 {
+		return r.ItemID == item.ItemID
+	}
+	return r.Name != "" && r.Name == item.Name
+}
+
+// Tracker keeps the reservations of added items on disk and sends
+// escalating reminders before each is released. A nil Tracker ignores
+// everything.
+type Tracker struct {
+	mu        sync.Mutex
+	path      string
+	reminders []time.Duration
+	notifier  *notify.Notifier
+	items     map[string]*Reservation
+	now       func() time.Time
+}
+
+// NewTracker creates a tracker persisting to path, reminding the given
+// durations before each deadline (earliest first). Reservations left by a
+// previous run are picked up again.
+func NewTracker(path string, reminders []time.Duration, notifier *notify.Notifier) (*Tracker, error) {
+	items, err := LoadReservations(path)
+	if err != nil {
+		return nil, err
+	}
+	t := &Tracker{path: path, reminders: reminders, notifier: notifier, items: map[string]*Reservation{}, now: time.Now}
+	for i := range items {
+		t.items[items[i].Key()] = &items[i]
+	}
+	return t, nil
+}
+
+// LoadReservations reads the reservations saved at path, soonest deadline
+// first. A missing file means no reservations.
+func LoadReservations(path string) ([]Reservation, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read reservations: %w", err)
+	}
+	var items []Reservation
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("failed to parse reservations %s: %w", path, err)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Until.Before(items[j].Until) })
+	return items, nil
+}
+
+// Track records a newly reserved item, replacing an older reservation of
+// the same product
+func (t *Tracker) Track(r Reservation) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// Skip reminders whose time has already passed
+	left := r.Until.Sub(t.now())
+	for r.Reminded < len(t.reminders) && left <= t.reminders[r.Reminded] {
+		r.Reminded++
+	}
+	t.items[r.Key()] = &r
+	t.save()
+
+	console.Printf("⏳ %s reserved until %s (in %s, %s)\n", r.Name, r.Until.Format("15:04:05"), left.Round(time.Second), r.Source)
+}
+
+// Remove stops tracking the reservation with key, e.g. once the item was
+// ordered or taken out of the cart
+func (t *Tracker) Remove(key string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.items[key]; !ok {
+		return
+	}
+	delete(t.items, key)
+	t.save()
+}
+
+// List returns the reservations being tracked, earliest deadline first
+func (t *Tracker) List() []Reservation {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	list := make([]Reservation, 0, len(t.items))
+	for _, r := range t.items {
+		list = append(list, *r)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Until.Before(list[j].Until) })
+	return list
+}
+
+// Run sends reminders until ctx is cancelled
+func (t *Tracker) Run(ctx context.Context) {
+	if t == nil {
+		return
+	}
+	ticker := time.NewTicker(reminderTick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			t.check(ctx, now)
+		}
+	}
+}
+
+// reminder is a notification due for one reservation
+type reminder struct {
+	level notify.Level
+	title string
+	msg   string
+}
+
+// check sends the reminders that came due and drops released items
+func (t *Tracker) check(ctx context.Context, now time.Time) {
+	t.mu.Lock()
+	var due []reminder
+	changed := false
+	for key, r := range t.items {
+		left := r.Until.Sub(now)
+		if left <= 0 {
+			due = append(due, reminder{notify.Critical, "Cart reservation ended",
+				fmt.Sprintf("%s (stream %d) is no longer reserved and may be released from the cart", r.Name, r.StreamID)})
+			delete(t.items, key)
+			changed = true
+			continue
+		}
+
+		sent := r.Reminded
+		for r.Reminded < len(t.reminders) && left <= t.reminders[r.Reminded] {
+			r.Reminded++
+		}
+		if r.Reminded > sent {
+			due = append(due, reminder{t.level(r.Reminded), "Check out reserved item",
+				fmt.Sprintf("%s (stream %d) is reserved for %s more, until %s", r.Name, r.StreamID, left.Round(time.Second), r.Until.Format("15:04:05"))})
+			changed = true
+		}
+	}
+	if changed {
+		t.save()
+	}
+	t.mu.Unlock()
+
+	for _, d := range due {
+		t.notifier.Send(ctx, d.level, d.title, d.msg)
+	}
+}
+
+// level escalates with each reminder: the last is critical, the first of
+// several is informational
+func (t *Tracker) level(sent int) notify.Level {
+	switch {
+	case sent >= len(t.reminders):
+		return notify.Critical
+	case sent == 1:
+		return notify.Info
+	default:
+		return notify.Warning
+	}
+}
+
+// Flush writes the tracked reservations to disk
+func (t *Tracker) Flush() error {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.write()
+}
+
+// save writes the reservations to disk, reporting failures; the caller
+// holds t.mu
+func (t *Tracker) save() {
+	if err := t.write(); err != nil {
+		console.Printf("⚠️  Failed to save reservations: %v\n", err)
+	}
+}
+
+// write writes the reservations to disk; the caller holds t.mu
+func (t *Tracker) write() error {
+	items := make([]*Reservation, 0, len(t.items))
+	for _, r := range t.items {
+		items = append(items, r)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Until.Before(items[j].Until) })
+
+	data, err := json.MarshalIndent(items, "", "  ")
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(t.path), 0755)
+	}
+	if err == nil {
+		err = os.WriteFile(t.path, data, 0644)
+	}
+	return err
+}
+
+// ApplyReservations fills in the reservation deadline of cart items the page
+// didn't show one for
+func ApplyReservations(c *Cart, reservations []Reservation) {
+	for i := range c.Items {
+		if c.Items[i].Reserved() {
+			continue
+		}
+		for _, r := range reservations {
+			if r.Matches(c.Items[i]) {
+				c.Items[i].ReservedUntil = r.Until
+				break
+			}
+		}
+	}
+}
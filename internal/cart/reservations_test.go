@@ -0,0 +1,183 @@
+package cart
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/LLionNg/shopee-livestream-bot/internal/config"
+	"github.com/LLionNg/shopee-livestream-bot/internal/notify"
+)
+
+// webhook collects the notifications posted to it
+type webhook struct {
+	mu     sync.Mutex
+	events []notify.Event
+}
+
+func (w *webhook) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	var ev notify.Event
+	json.NewDecoder(r.Body).Decode(&ev)
+	w.mu.Lock()
+	w.events = append(w.events, ev)
+	w.mu.Unlock()
+}
+
+// take returns the notifications received since the last call
+func (w *webhook) take() []notify.Event {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	events := w.events
+	w.events = nil
+	return events
+}
+
+func newTestTracker(t *testing.T, path string, clock *time.Time) (*Tracker, *webhook) {
+	t.Helper()
+	hook := &webhook{}
+	srv := httptest.NewServer(hook)
+	t.Cleanup(srv.Close)
+
+	notifier := notify.New(config.NotificationConfig{Enabled: true, WebhookURL: srv.URL})
+	tr, err := NewTracker(path, []time.Duration{5 * time.Minute, time.Minute}, notifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr.now = func() time.Time { return *clock }
+	return tr, hook
+}
+
+func TestTrackerReminders(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reservations.json")
+	start := time.Date(2024, 5, 18, 20, 0, 0, 0, time.UTC)
+	clock := start
+	tr, hook := newTestTracker(t, path, &clock)
+	ctx := context.Background()
+
+	tr.Track(Reservation{ShopID: 11, ItemID: 22, Name: "Test Mug", StreamID: 1, AddedAt: start, Until: start.Add(10 * time.Minute), Source: DeadlinePage})
+
+	steps := []struct {
+		after time.Duration
+		level notify.Level // "" for no notification
+		title string
+	}{
+		{4 * time.Minute, "", ""},
+		{5 * time.Minute, notify.Info, "Check out reserved item"},
+		{8 * time.Minute, "", ""},
+		{9 * time.Minute, notify.Critical, "Check out reserved item"},
+		{9*time.Minute + 30*time.Second, "", ""},
+		{10 * time.Minute, notify.Critical, "Cart reservation ended"},
+	}
+	for _, step := range steps {
+		clock = start.Add(step.after)
+		tr.check(ctx, clock)
+		events := hook.take()
+		switch {
+		case step.level == "" && len(events) != 0:
+			t.Errorf("at +%s: got %+v, want no notification", step.after, events)
+		case step.level != "" && (len(events) != 1 || events[0].Level != step.level || events[0].Title != step.title):
+			t.Errorf("at +%s: got %+v, want one %s %q", step.after, events, step.level, step.title)
+		}
+	}
+
+	if list := tr.List(); len(list) != 0 {
+		t.Errorf("released reservation still tracked: %+v", list)
+	}
+	if saved, err := LoadReservations(path); err != nil || len(saved) != 0 {
+		t.Errorf("saved reservations = %+v, %v; want none", saved, err)
+	}
+}
+
+func TestTrackerRefresh(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reservations.json")
+	start := time.Date(2024, 5, 18, 20, 0, 0, 0, time.UTC)
+	clock := start
+	tr, hook := newTestTracker(t, path, &clock)
+	ctx := context.Background()
+
+	// Added with three minutes left: the 5-minute reminder is already past
+	mug := Reservation{ShopID: 11, ItemID: 22, Name: "Test Mug", Until: start.Add(3 * time.Minute), Source: DeadlineConfig}
+	tr.Track(mug)
+	tr.check(ctx, clock)
+	if events := hook.take(); len(events) != 0 {
+		t.Errorf("late reservation sent %+v, want no catch-up reminder", events)
+	}
+
+	// Added again later: the new deadline replaces the old one and the
+	// reminders start over
+	clock = start.Add(2 * time.Minute)
+	mug.Until = clock.Add(15 * time.Minute)
+	mug.Source = DeadlinePage
+	tr.Track(mug)
+	clock = start.Add(3 * time.Minute)
+	tr.check(ctx, clock)
+	if events := hook.take(); len(events) != 0 {
+		t.Errorf("refreshed reservation sent %+v at the old deadline", events)
+	}
+	list := tr.List()
+	if len(list) != 1 || !list[0].Until.Equal(mug.Until) || list[0].Reminded != 0 {
+		t.Fatalf("tracked = %+v, want the refreshed reservation", list)
+	}
+
+	// A restart picks the reservation up from disk
+	again, _ := newTestTracker(t, path, &clock)
+	if list := again.List(); len(list) != 1 || !list[0].Until.Equal(mug.Until) || list[0].Source != DeadlinePage {
+		t.Errorf("reloaded = %+v, want the refreshed reservation", list)
+	}
+}
+
+func TestApplyReservations(t *testing.T) {
+	until := time.Date(2024, 5, 18, 20, 15, 0, 0, time.UTC)
+	page := until.Add(-time.Minute)
+	c := &Cart{Items: []Item{
+		{ShopID: 11, ItemID: 22, Name: "Test Mug"},
+		{ShopID: 33, ItemID: 44, Name: "Green Tea", ReservedUntil: page},
+		{Name: "Mystery box"},
+	}}
+	ApplyReservations(c, []Reservation{
+		{ShopID: 11, ItemID: 22, Name: "Mug", Until: until},
+		{ShopID: 33, ItemID: 44, Until: until},
+		{Name: "Mystery box", Until: until},
+	})
+
+	for i, want := range []time.Time{until, page, until} {
+		if !c.Items[i].ReservedUntil.Equal(want) {
+			t.Errorf("%s reserved until %s, want %s", c.Items[i].Name, c.Items[i].ReservedUntil, want)
+		}
+	}
+}
+
+func TestTrackerRemove(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reservations.json")
+	start := time.Date(2024, 5, 18, 20, 0, 0, 0, time.UTC)
+	clock := start
+	tr, hook := newTestTracker(t, path, &clock)
+	ctx := context.Background()
+
+	mug := Reservation{ShopID: 11, ItemID: 22, Name: "Test Mug", Until: start.Add(10 * time.Minute)}
+	tea := Reservation{ShopID: 33, ItemID: 44, Name: "Green Tea", Until: start.Add(10 * time.Minute)}
+	tr.Track(mug)
+	tr.Track(tea)
+
+	// The mug was ordered, the tea taken out of the cart
+	tr.Remove(mug.Key())
+	tr.Remove(tea.Key())
+	tr.Remove("no such reservation")
+	if saved, err := LoadReservations(path); err != nil || len(saved) != 0 {
+		t.Errorf("saved reservations = %+v, %v; want none", saved, err)
+	}
+
+	// Neither a reminder nor the end of the reservation is announced
+	for _, after := range []time.Duration{5 * time.Minute, 9 * time.Minute, 10 * time.Minute} {
+		clock = start.Add(after)
+		tr.check(ctx, clock)
+	}
+	if events := hook.take(); len(events) != 0 {
+		t.Errorf("removed reservations sent %+v", events)
+	}
+}
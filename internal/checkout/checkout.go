@@ -0,0 +1,515 @@
+package checkout
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/LLionNg/shopee-livestream-bot/internal/auth"
+	"github.com/LLionNg/shopee-livestream-bot/internal/browser"
+	"github.com/LLionNg/shopee-livestream-bot/internal/cart"
+	"github.com/LLionNg/shopee-livestream-bot/internal/config"
+	"github.com/LLionNg/shopee-livestream-bot/internal/console"
+	"github.com/LLionNg/shopee-livestream-bot/internal/evidence"
+	"github.com/LLionNg/shopee-livestream-bot/internal/notify"
+	"github.com/LLionNg/shopee-livestream-bot/internal/product"
+	"github.com/LLionNg/shopee-livestream-bot/internal/selectors"
+	"github.com/chromedp/chromedp"
+)
+
+var (
+	// ErrDisabled is returned when checkout.enabled is off
+	ErrDisabled = errors.New("automatic checkout is disabled")
+	// ErrOverBudget means the order would exceed the spending limits
+	ErrOverBudget = errors.New("order exceeds the checkout budget")
+	// ErrNotApproved means the operator declined or didn't answer in time
+	ErrNotApproved = errors.New("order was not approved")
+	// ErrCheckoutMismatch means the checkout page doesn't show the
+	// configured address, shipping option or payment method
+	ErrCheckoutMismatch = errors.New("checkout page does not match the configured choices")
+	// ErrAlreadyOrdered means an order for the reserved item is under way
+	// or was already placed
+	ErrAlreadyOrdered = errors.New("item is already being ordered")
+)
+
+var (
+	// orderURLPattern finds an order ID in the confirmation page URL
+	orderURLPattern = regexp.MustCompile(`(?i)(?:order_?id|order_?sn)=([A-Za-z0-9]+)|/order/(\d+)`)
+	// orderLabelPattern finds the order ID after its label in the
+	// confirmation text, e.g. "Order ID: 240518ABCD12"
+	orderLabelPattern = regexp.MustCompile(`(?i)(?:order\s*(?:id|no\.?|number|sn)|หมายเลขคำสั่งซื้อ)\s*[:#]?\s*([0-9A-Z]*[0-9][0-9A-Z]*)\b`)
+	// orderTextPattern finds an unlabelled order ID: six or more capitals
+	// and digits, at least one of them a digit
+	orderTextPattern = regexp.MustCompile(`\b[0-9A-Z]*[0-9][0-9A-Z]*\b`)
+)
+
+const (
+	// pageTimeout bounds each wait for the checkout and confirmation pages
+	pageTimeout = 30 * time.Second
+)
+
+// Checkout places orders for items already in the cart, guarded by the
+// spending budget and an optional operator approval. It only exists when
+// checkout.enabled is set; a nil Checkout refuses every order.
+type Checkout struct {
+	cfg      *config.Config
+	sel      *selectors.Store
+	cart     *cart.Manager
+	approver auth.CodeSource
+	evidence *evidence.Collector
+	notifier *notify.Notifier
+	// reservations stops reminding about items once they are ordered
+	reservations *cart.Tracker
+
+	// approving lets one order at a time ask for approval, so a reply can
+	// only ever answer the question it was given for
+	approving chan struct{}
+
+	mu      sync.Mutex
+	orders  []Order
+	pending int64
+	// ordering holds the reservation keys with an order under way (false)
+	// or placed (true), so a product is only checked out once
+	ordering map[string]bool
+}
+
+// New returns a checkout for the config, or nil when checkout is disabled.
+// Ordered items are dropped from reservations.
+func New(cfg *config.Config, sel *selectors.Store, ev *evidence.Collector, notifier *notify.Notifier, reservations *cart.Tracker) (*Checkout, error) {
+	if !cfg.Checkout.Enabled {
+		return nil, nil
+	}
+
+	approver, err := auth.NewCodeSource(config.VerificationConfig{
+		Sources:  cfg.Checkout.Confirm.Sources,
+		Telegram: cfg.Auth.Verification.Telegram,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("checkout.confirm: %w", err)
+	}
+	orders, err := LoadOrders(cfg.Checkout.OrdersFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Checkout{
+		cfg:      cfg,
+		sel:      sel,
+		cart:     cart.NewManager(cfg, sel),
+		approver: approver,
+		evidence: ev,
+		notifier: notifier,
+		orders:   orders,
+		ordering: map[string]bool{},
+
+		reservations: reservations,
+
+		approving: make(chan struct{}, 1),
+	}, nil
+}
+
+// Place checks out the reserved item in a new tab of the browser behind
+// ctx: it selects only that item in the cart, verifies the address,
+// shipping and payment, enforces the budget, asks for approval and places
+// the order. An item already being ordered, or ordered before, is skipped
+// with ErrAlreadyOrdered.
+func (c *Checkout) Place(ctx context.Context, r cart.Reservation) (*Order, error) {
+	if c == nil {
+		return nil, ErrDisabled
+	}
+	if !c.claim(r.Key()) {
+		return nil, fmt.Errorf("%s: %w", r.Name, ErrAlreadyOrdered)
+	}
+	placed := false
+	defer func() {
+		if !placed {
+			c.unclaim(r.Key())
+		}
+	}()
+
+	tab, cancel := chromedp.NewContext(ctx)
+	defer cancel()
+	c.evidence.Attach(tab)
+
+	order, err := c.place(tab, r)
+	placed = order != nil
+
+	reason := "checkout-success"
+	if err != nil {
+		reason = "checkout-failure"
+	}
+	if dir, captureErr := c.evidence.Capture(tab, evidence.Info{Reason: reason, StreamID: r.StreamID}, err); captureErr != nil {
+		console.Printf("⚠️  Evidence capture incomplete (%s): %v\n", dir, captureErr)
+	}
+
+	if err != nil {
+		c.notifier.Send(ctx, notify.Warning, "Checkout stopped", fmt.Sprintf("%s: %v", r.Name, err))
+		return nil, err
+	}
+	c.notifier.Send(ctx, notify.Info, "Order placed",
+		fmt.Sprintf("%s x%d for %s, order %s", order.Name, order.Quantity, order.Total, order.ID))
+	return order, nil
+}
+
+func (c *Checkout) place(tab context.Context, r cart.Reservation) (*Order, error) {
+	console.Printf("💳 Checking out %s...\n", r.Name)
+
+	item, err := c.cart.SelectOnly(tab, r)
+	if err != nil {
+		return nil, err
+	}
+	// Fail fast on the cart price before the checkout page loads
+	if err := c.checkBudget(item.Subtotal().Amount); err != nil {
+		return nil, err
+	}
+
+	if err := c.click(tab, selectors.CartCheckout); err != nil {
+		return nil, fmt.Errorf("failed to start checkout: %w", err)
+	}
+	if err := c.waitFor(tab, selectors.CheckoutTotal); err != nil {
+		return nil, fmt.Errorf("checkout page did not load: %w", err)
+	}
+
+	order := &Order{
+		ShopID:   item.ShopID,
+		ItemID:   item.ItemID,
+		Name:     item.Name,
+		Quantity: item.Quantity,
+		StreamID: r.StreamID,
+	}
+	if order.Name == "" {
+		order.Name = r.Name
+	}
+	if err := c.verifyChoices(tab, order); err != nil {
+		return nil, err
+	}
+
+	totalText, err := c.sel.Text(tab, selectors.CheckoutTotal)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read order total: %w", err)
+	}
+	order.Total, err = product.ParseMoney(totalText, c.cfg.Shopee.GetRegion().Currency)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read order total: %w", err)
+	}
+
+	// Hold the amount against the budget until the order is placed or dropped
+	if err := c.reserve(order.Total.Amount); err != nil {
+		return nil, err
+	}
+	placed := false
+	defer func() {
+		if !placed {
+			c.release(order.Total.Amount)
+		}
+	}()
+
+	if err := c.approve(tab, order); err != nil {
+		return nil, err
+	}
+
+	if err := c.click(tab, selectors.CheckoutPlaceOrder); err != nil {
+		return nil, fmt.Errorf("failed to place order: %w", err)
+	}
+
+	// From here on the order may exist, so it counts against the budget
+	// even if its ID can't be read
+	placed = true
+	order.PlacedAt = time.Now()
+	order.ID, order.URL = c.readOrderID(tab)
+	if err := c.record(order); err != nil {
+		console.Printf("⚠️  Failed to record order: %v\n", err)
+	} else {
+		c.settle(r.Key())
+		c.reservations.Remove(r.Key())
+	}
+	if order.ID == "" {
+		return order, fmt.Errorf("order was submitted but no order ID was found on %s, check the order list", order.URL)
+	}
+
+	console.Printf("✅ Order %s placed: %s x%d for %s\n", order.ID, order.Name, order.Quantity, order.Total)
+	return order, nil
+}
+
+// verifyChoices checks that the checkout page uses the configured address
+// and shipping option, and switches to the configured payment method
+func (c *Checkout) verifyChoices(tab context.Context, order *Order) error {
+	var err error
+	if order.Address, err = c.expect(tab, selectors.CheckoutAddress, c.cfg.Checkout.Address); err != nil {
+		return err
+	}
+	if order.Shipping, err = c.expect(tab, selectors.CheckoutShipping, c.cfg.Checkout.Shipping); err != nil {
+		return err
+	}
+
+	want := c.cfg.Checkout.Payment
+	if order.Payment, err = c.expect(tab, selectors.CheckoutPayment, want); err == nil {
+		return nil
+	}
+	if err := c.choosePayment(tab, want); err != nil {
+		return err
+	}
+	order.Payment, err = c.expect(tab, selectors.CheckoutPayment, want)
+	return err
+}
+
+// expect reads an element and requires it to contain want; with an empty
+// want the element only has to show something
+func (c *Checkout) expect(tab context.Context, name, want string) (string, error) {
+	text, err := c.sel.Text(tab, name)
+	text = strings.Join(strings.Fields(text), " ")
+	if err != nil || text == "" {
+		return "", fmt.Errorf("%w: no %s shown", ErrCheckoutMismatch, name)
+	}
+	if want != "" && !strings.Contains(strings.ToLower(text), strings.ToLower(want)) {
+		return "", fmt.Errorf("%w: %s is %q, expected %q", ErrCheckoutMismatch, name, text, want)
+	}
+	return text, nil
+}
+
+// choosePayment clicks the payment option whose caption contains want
+func (c *Checkout) choosePayment(tab context.Context, want string) error {
+	options, _ := json.Marshal(c.sel.Get(selectors.CheckoutPayOption))
+	caption, _ := json.Marshal(strings.ToLower(want))
+	script := fmt.Sprintf(`(() => {
+		for (const s of %s) {
+			for (const el of document.querySelectorAll(s)) {
+				if ((el.innerText || '').toLowerCase().includes(%s)) {
+					el.click();
+					return true;
+				}
+			}
+		}
+		return false;
+	})()`, options, caption)
+
+	var clicked bool
+	if err := chromedp.Run(tab, chromedp.Evaluate(script, &clicked), chromedp.Sleep(time.Second)); err != nil {
+		return fmt.Errorf("failed to choose payment method: %w", err)
+	}
+	if !clicked {
+		return fmt.Errorf("%w: no payment option %q", ErrCheckoutMismatch, want)
+	}
+	return nil
+}
+
+// approve asks the operator to confirm the order when a confirmation
+// source is configured. The reply must repeat the order's approval code.
+func (c *Checkout) approve(tab context.Context, order *Order) error {
+	if c.approver == nil {
+		return nil
+	}
+
+	// Orders from several streams take turns
+	select {
+	case c.approving <- struct{}{}:
+		defer func() { <-c.approving }()
+	case <-tab.Done():
+		return fmt.Errorf("%w: %v", ErrNotApproved, tab.Err())
+	}
+
+	code, err := approvalCode()
+	if err != nil {
+		return err
+	}
+	timeout := c.cfg.Checkout.Confirm.GetTimeout()
+	ctx, cancel := context.WithTimeout(tab, timeout)
+	defer cancel()
+
+	answer, err := c.approver.Code(ctx, auth.Challenge{
+		Kind: auth.ChallengeApproval,
+		Prompt: fmt.Sprintf("Place order: %s x%d for %s, paying with %s, shipping to %s? Reply \"yes %s\" within %s.",
+			order.Name, order.Quantity, order.Total, order.Payment, order.Address, code, timeout),
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrNotApproved, err)
+	}
+	if !approved(answer, code) {
+		return fmt.Errorf("%w: answered %q", ErrNotApproved, answer)
+	}
+	return nil
+}
+
+// approvalCode returns a random 4-digit code binding a reply to one order
+func approvalCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(10000))
+	if err != nil {
+		return "", fmt.Errorf("failed to generate approval code: %w", err)
+	}
+	return fmt.Sprintf("%04d", n.Int64()), nil
+}
+
+// approved reports whether answer says yes and repeats code
+func approved(answer, code string) bool {
+	fields := strings.Fields(strings.ToLower(answer))
+	if len(fields) != 2 || fields[1] != code {
+		return false
+	}
+	switch fields[0] {
+	case "y", "yes", "ok", "approve":
+		return true
+	}
+	return false
+}
+
+// readOrderID waits for the confirmation page and reads the order ID from
+// its URL or the order ID element
+func (c *Checkout) readOrderID(tab context.Context) (string, string) {
+	deadline := time.Now().Add(pageTimeout)
+	var location string
+	for time.Now().Before(deadline) {
+		chromedp.Run(tab, chromedp.Location(&location))
+		if m := orderURLPattern.FindStringSubmatch(location); m != nil {
+			return m[1] + m[2], location
+		}
+		if text, err := c.sel.Text(tab, selectors.OrderID); err == nil {
+			if id := parseOrderID(text); id != "" {
+				return id, location
+			}
+		}
+		if browser.Sleep(tab, time.Second) != nil {
+			break
+		}
+	}
+	return "", location
+}
+
+// parseOrderID finds the order ID in the confirmation text, preferring the
+// one after an order number label
+func parseOrderID(text string) string {
+	if m := orderLabelPattern.FindStringSubmatch(text); m != nil && len(m[1]) >= 6 {
+		return m[1]
+	}
+	for _, id := range orderTextPattern.FindAllString(text, -1) {
+		if len(id) >= 6 {
+			return id
+		}
+	}
+	return ""
+}
+
+// click finds an element and clicks it
+func (c *Checkout) click(tab context.Context, name string) error {
+	selector, err := c.sel.Find(tab, name)
+	if err != nil {
+		return err
+	}
+	return browser.Click(tab, selector)
+}
+
+// waitFor polls until an element is on the page
+func (c *Checkout) waitFor(tab context.Context, name string) error {
+	deadline := time.Now().Add(pageTimeout)
+	for !c.sel.Exists(tab, name) {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%s not found after %s", name, pageTimeout)
+		}
+		if err := chromedp.Run(tab, chromedp.Sleep(500*time.Millisecond)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Left returns how much of the budget for the current period is neither
+// spent nor held by an order under way
+func (c *Checkout) Left() product.Money {
+	if c == nil {
+		return product.Money{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	spent := spentSince(c.orders, time.Now().Add(-c.cfg.Checkout.GetBudgetPeriod())) + c.pending
+	return product.Money{Amount: c.minor(c.cfg.Checkout.Budget) - spent, Currency: c.cfg.Shopee.GetRegion().Currency}
+}
+
+// minor converts an amount in currency units from the config to minor units
+func (c *Checkout) minor(amount float64) int64 {
+	return int64(math.Round(amount * math.Pow10(c.cfg.Shopee.GetRegion().Currency.MinorDigits)))
+}
+
+// checkBudget reports whether an order of amount would fit the limits
+func (c *Checkout) checkBudget(amount int64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.fits(amount)
+}
+
+// fits checks amount against the limits; the caller holds c.mu
+func (c *Checkout) fits(amount int64) error {
+	currency := c.cfg.Shopee.GetRegion().Currency
+	if limit := c.minor(c.cfg.Checkout.MaxOrder); limit > 0 && amount > limit {
+		return fmt.Errorf("%w: %s is above checkout.max_order %s", ErrOverBudget, currency.Format(amount), currency.Format(limit))
+	}
+	spent := spentSince(c.orders, time.Now().Add(-c.cfg.Checkout.GetBudgetPeriod())) + c.pending
+	if budget := c.minor(c.cfg.Checkout.Budget); spent+amount > budget {
+		return fmt.Errorf("%w: %s spent or pending, %s more would pass %s", ErrOverBudget, currency.Format(spent), currency.Format(amount), currency.Format(budget))
+	}
+	return nil
+}
+
+// reserve holds amount against the budget
+func (c *Checkout) reserve(amount int64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.fits(amount); err != nil {
+		return err
+	}
+	c.pending += amount
+	return nil
+}
+
+// release returns a held amount to the budget
+func (c *Checkout) release(amount int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pending -= amount
+}
+
+// record turns the held amount into a placed order and saves it
+// claim marks key as being ordered, reporting false when it already is or
+// was ordered
+func (c *Checkout) claim(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.ordering[key]; ok {
+		return false
+	}
+	if c.ordering == nil {
+		c.ordering = map[string]bool{}
+	}
+	c.ordering[key] = false
+	return true
+}
+
+// unclaim frees key after an order that was never submitted
+func (c *Checkout) unclaim(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.ordering[key] {
+		delete(c.ordering, key)
+	}
+}
+
+// settle marks key as ordered for good
+func (c *Checkout) settle(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ordering[key] = true
+}
+
+func (c *Checkout) record(order *Order) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pending -= order.Total.Amount
+	c.orders = append(c.orders, *order)
+	return saveOrders(c.cfg.Checkout.OrdersFile, c.orders)
+}
@@ -0,0 +1,199 @@
+package checkout
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LLionNg/shopee-livestream-bot/internal/browser"
+	"github.com/LLionNg/shopee-livestream-bot/internal/cart"
+	"github.com/LLionNg/shopee-livestream-bot/internal/config"
+	"github.com/LLionNg/shopee-livestream-bot/internal/product"
+	"github.com/LLionNg/shopee-livestream-bot/internal/selectors"
+	"github.com/chromedp/chromedp"
+)
+
+// TestPlaceLocalPages runs the checkout against the stand-in pages in
+// testdata. It needs Chrome and is skipped without it.
+func TestPlaceLocalPages(t *testing.T) {
+	execPath, err := browser.FindChrome("")
+	if err != nil {
+		t.Skipf("Chrome not available: %v", err)
+	}
+
+	srv := httptest.NewServer(http.FileServer(http.Dir("testdata")))
+	defer srv.Close()
+
+	cfg := &config.Config{
+		Shopee: config.ShopeeConfig{Region: "th", LivestreamURLs: []string{srv.URL}},
+		Cart:   config.CartConfig{PageURL: srv.URL + "/cart.html"},
+		Checkout: config.CheckoutConfig{
+			Enabled:    true,
+			Budget:     200,
+			Address:    "Test Road",
+			Payment:    "ShopeePay",
+			Confirm:    config.ConfirmConfig{Sources: []string{}},
+			OrdersFile: filepath.Join(t.TempDir(), "orders.json"),
+		},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	sel, err := selectors.NewStore("../../configs/selectors/default.yaml", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	allocCtx, cancel := chromedp.NewExecAllocator(context.Background(),
+		append(chromedp.DefaultExecAllocatorOptions[:], chromedp.ExecPath(execPath))...)
+	defer cancel()
+	ctx, cancel := chromedp.NewContext(allocCtx)
+	defer cancel()
+	ctx, cancel = context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+	if err := chromedp.Run(ctx); err != nil {
+		t.Skipf("Chrome failed to start: %v", err)
+	}
+
+	co, err := New(cfg, sel, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mug := cart.Reservation{ShopID: 11, ItemID: 22, Name: "Test Mug"}
+
+	order, err := co.Place(ctx, mug)
+	if err != nil {
+		t.Fatalf("Place: %v", err)
+	}
+	if !strings.HasPrefix(order.ID, "TEST") {
+		t.Errorf("order ID = %q, want TEST...", order.ID)
+	}
+	if order.Total.Amount != 15000 {
+		t.Errorf("total = %s, want only the mug (฿150)", order.Total)
+	}
+	if !strings.Contains(order.Payment, "ShopeePay") {
+		t.Errorf("payment = %q, want ShopeePay", order.Payment)
+	}
+
+	orders, err := LoadOrders(cfg.Checkout.OrdersFile)
+	if err != nil || len(orders) != 1 || orders[0].ID != order.ID {
+		t.Fatalf("recorded orders = %+v, %v", orders, err)
+	}
+
+	// The same reservation is only ordered once
+	if _, err := co.Place(ctx, mug); !errors.Is(err, ErrAlreadyOrdered) {
+		t.Errorf("same reservation again: got %v, want ErrAlreadyOrdered", err)
+	}
+
+	// ฿150 of the ฿200 budget is spent, so another mug must be refused
+	if _, err := co.Place(ctx, cart.Reservation{Name: "Test Mug"}); !errors.Is(err, ErrOverBudget) {
+		t.Errorf("second order: got %v, want ErrOverBudget", err)
+	}
+}
+
+func TestFits(t *testing.T) {
+	cfg := &config.Config{
+		Shopee:   config.ShopeeConfig{Region: "th", LivestreamURLs: []string{"https://th.shp.ee/test"}},
+		Checkout: config.CheckoutConfig{Budget: 500, MaxOrder: 300},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	c := &Checkout{cfg: cfg, orders: []Order{
+		{PlacedAt: time.Now().Add(-time.Hour), Total: product.Money{Amount: 10000}},
+		{PlacedAt: time.Now().Add(-48 * time.Hour), Total: product.Money{Amount: 40000}}, // outside the period
+	}}
+
+	tests := []struct {
+		amount int64
+		ok     bool
+	}{
+		{20000, true},
+		{30000, true},
+		{30001, false}, // above max_order
+	}
+	for _, tt := range tests {
+		if err := c.fits(tt.amount); (err == nil) != tt.ok {
+			t.Errorf("fits(%d) = %v, want ok=%v", tt.amount, err, tt.ok)
+		}
+	}
+
+	// Pending amounts count against the budget until released
+	if err := c.reserve(30000); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.reserve(20000); !errors.Is(err, ErrOverBudget) {
+		t.Errorf("reserve over budget: got %v", err)
+	}
+	c.release(30000)
+	if err := c.reserve(20000); err != nil {
+		t.Errorf("reserve after release: %v", err)
+	}
+}
+
+func TestParseOrderID(t *testing.T) {
+	tests := []struct {
+		text, want string
+	}{
+		{"Order ID: 240518ABCD12", "240518ABCD12"},
+		{"Thank you for your order! Order No. 2405187QWERTY will be shipped by Flash Express", "2405187QWERTY"},
+		{"ขอบคุณสำหรับคำสั่งซื้อ หมายเลขคำสั่งซื้อ 240518ABCD12 ติดตามพัสดุ", "240518ABCD12"},
+		{"Payment successful. Your order 240518ABCD12 has been placed. Shipping via SPX Express", "240518ABCD12"},
+		{"Your order has been placed. Thanks for shopping with Shopee", ""},
+	}
+	for _, tt := range tests {
+		if got := parseOrderID(tt.text); got != tt.want {
+			t.Errorf("parseOrderID(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestApproved(t *testing.T) {
+	tests := []struct {
+		answer string
+		ok     bool
+	}{
+		{"yes 0427", true},
+		{"  OK 0427 ", true},
+		{"yes", false},      // no code
+		{"yes 1234", false}, // another order's code
+		{"no 0427", false},
+		{"yes 0427 please", false},
+	}
+	for _, tt := range tests {
+		if got := approved(tt.answer, "0427"); got != tt.ok {
+			t.Errorf("approved(%q) = %v, want %v", tt.answer, got, tt.ok)
+		}
+	}
+}
+
+func TestPlaceOnce(t *testing.T) {
+	c := &Checkout{}
+	mug := cart.Reservation{ShopID: 11, ItemID: 22, Name: "Test Mug"}
+
+	// An order under way: a second Place returns before opening a tab
+	if !c.claim(mug.Key()) {
+		t.Fatal("first claim refused")
+	}
+	if order, err := c.Place(context.Background(), mug); order != nil || !errors.Is(err, ErrAlreadyOrdered) {
+		t.Errorf("Place while ordering = %v, %v; want ErrAlreadyOrdered", order, err)
+	}
+
+	// An order that was never submitted frees the item again
+	c.unclaim(mug.Key())
+	if !c.claim(mug.Key()) {
+		t.Fatal("claim after a dropped order refused")
+	}
+
+	// A placed order keeps it taken
+	c.settle(mug.Key())
+	c.unclaim(mug.Key())
+	if order, err := c.Place(context.Background(), mug); order != nil || !errors.Is(err, ErrAlreadyOrdered) {
+		t.Errorf("Place after ordering = %v, %v; want ErrAlreadyOrdered", order, err)
+	}
+}
@@ -0,0 +1,71 @@
+package checkout
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/LLionNg/shopee-livestream-bot/internal/product"
+)
+
+// Order is a placed order as recorded by the bot
+type Order struct {
+	ID       string        `json:"id"`
+	PlacedAt time.Time     `json:"placed_at"`
+	ShopID   int64         `json:"shop_id,omitempty"`
+	ItemID   int64         `json:"item_id,omitempty"`
+	Name     string        `json:"name"`
+	Quantity int           `json:"quantity"`
+	Total    product.Money `json:"total"`
+	Address  string        `json:"address"`
+	Shipping string        `json:"shipping"`
+	Payment  string        `json:"payment"`
+	StreamID int           `json:"stream_id,omitempty"`
+	URL      string        `json:"url"`
+}
+
+// LoadOrders reads the orders recorded at path, oldest first. A missing
+// file means no orders.
+func LoadOrders(path string) ([]Order, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read orders: %w", err)
+	}
+	var orders []Order
+	if err := json.Unmarshal(data, &orders); err != nil {
+		return nil, fmt.Errorf("failed to parse orders %s: %w", path, err)
+	}
+	return orders, nil
+}
+
+// saveOrders writes the orders to path
+func saveOrders(path string, orders []Order) error {
+	data, err := json.MarshalIndent(orders, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// spentSince sums the totals of orders placed at or after since
+func spentSince(orders []Order, since time.Time) int64 {
+	var total int64
+	for _, o := range orders {
+		if !o.PlacedAt.Before(since) {
+			total += o.Total.Amount
+		}
+	}
+	return total
+}
@@ -0,0 +1,22 @@
+package cli
+
+import (
+	"errors"
+
+	"github.com/LLionNg/shopee-livestream-bot/internal/browser"
+)
+
+// BrowserHint tells the user what to do about a browser.New error
+func BrowserHint(err error) string {
+	switch {
+	case errors.Is(err, browser.ErrBinaryNotFound):
+		return "install Chrome or point browser.exec_path at it; run `bot doctor` to see what was searched"
+	case errors.Is(err, browser.ErrUserDataDirLocked):
+		return "another Chrome is using the browser profile; close it, pick another profile or set browser.clone_profile (see `bot profile list`)"
+	case errors.Is(err, browser.ErrProtocolMismatch):
+		return "update Chrome to a current release; run `bot doctor` for details"
+	case errors.Is(err, browser.ErrLaunchFailed):
+		return "check that Chrome starts on its own, or that browser.remote_url is reachable"
+	}
+	return ""
+}
@@ -0,0 +1,191 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/LLionNg/shopee-livestream-bot/internal/browser"
+	"github.com/LLionNg/shopee-livestream-bot/internal/cart"
+	"github.com/LLionNg/shopee-livestream-bot/internal/config"
+	"github.com/LLionNg/shopee-livestream-bot/internal/selectors"
+)
+
+func init() {
+	register(Command{
+		Name:    "cart",
+		Usage:   "cart list|remove|qty|clear",
+		Summary: "Show or edit the cart (remove <n|id>..., qty <n|id> <count>)",
+		Run:     runCart,
+	})
+}
+
+func runCart(configPath string, args []string) error {
+	usage := fmt.Errorf("usage: bot cart list | remove <n|item-id>... | qty <n|item-id> <count> | clear")
+	if len(args) == 0 {
+		return usage
+	}
+
+	var quantity int
+	switch {
+	case args[0] == "list" && len(args) == 1, args[0] == "clear" && len(args) == 1:
+	case args[0] == "remove" && len(args) >= 2:
+	case args[0] == "qty" && len(args) == 3:
+		n, err := strconv.Atoi(args[2])
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid quantity %q", args[2])
+		}
+		quantity = n
+	default:
+		return usage
+	}
+
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return err
+	}
+	carts, session, err := openCart(cfg)
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+	ctx := session.Context()
+
+	// Items taken out of the cart aren't reserved any more
+	reservations, err := cart.NewTracker(cfg.Cart.ReservationsFile, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	if args[0] == "clear" {
+		if err := carts.Clear(ctx); err != nil {
+			return err
+		}
+		for _, r := range reservations.List() {
+			reservations.Remove(r.Key())
+		}
+		fmt.Println("🗑️  Cart cleared")
+		return nil
+	}
+
+	c, err := carts.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "list":
+		// The cart page doesn't always show the reservation clock
+		reservations, err := cart.LoadReservations(cfg.Cart.ReservationsFile)
+		if err != nil {
+			fmt.Printf("⚠️  %v\n", err)
+		}
+		cart.ApplyReservations(c, reservations)
+		printCart(c)
+	case "remove":
+		var items []cart.Item
+		for _, ref := range args[1:] {
+			item, err := c.Find(ref)
+			if err != nil {
+				return err
+			}
+			items = append(items, item)
+		}
+		if err := carts.Remove(ctx, items...); err != nil {
+			return err
+		}
+		forget(reservations, items)
+		fmt.Printf("🗑️  Removed %d item(s)\n", len(items))
+	case "qty":
+		item, err := c.Find(args[1])
+		if err != nil {
+			return err
+		}
+		if err := carts.SetQuantity(ctx, item, quantity); err != nil {
+			return err
+		}
+		if quantity == 0 {
+			forget(reservations, []cart.Item{item})
+		}
+		fmt.Printf("✏️  %s: quantity %d -> %d\n", item.Name, item.Quantity, quantity)
+	}
+	return nil
+}
+
+// forget drops the reservations of removed items
+func forget(reservations *cart.Tracker, items []cart.Item) {
+	for _, r := range reservations.List() {
+		for _, item := range items {
+			if r.Matches(item) {
+				reservations.Remove(r.Key())
+				break
+			}
+		}
+	}
+}
+
+// openCart starts a browser with the saved session loaded and returns a
+// cart manager for it
+func openCart(cfg *config.Config) (*cart.Manager, *browser.Session, error) {
+	sel, session, err := openLoggedIn(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cart.NewManager(cfg, sel), session, nil
+}
+
+// openLoggedIn starts a browser with the saved session loaded and checks
+// that it is still logged in
+func openLoggedIn(cfg *config.Config) (*selectors.Store, *browser.Session, error) {
+	m, session, err := openManager(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := m.LoadSession(session.Context()); err != nil {
+		session.Close()
+		return nil, nil, err
+	}
+	if !m.ValidateSession(session.Context()) {
+		session.Close()
+		return nil, nil, fmt.Errorf("saved session is not logged in, run the bot to log in first")
+	}
+
+	sel, err := selectors.NewStore(cfg.Shopee.SelectorsFile, cfg.Shopee.GetRegion().Texts)
+	if err != nil {
+		session.Close()
+		return nil, nil, err
+	}
+	return sel, session, nil
+}
+
+// printCart lists the items with their position for remove/qty, then the
+// per-shop and overall totals
+func printCart(c *cart.Cart) {
+	if len(c.Items) == 0 {
+		fmt.Println("🛒 Cart is empty")
+		return
+	}
+
+	now := time.Now()
+	fmt.Printf("🛒 %d item(s), %d unit(s) (from %s)\n", len(c.Items), c.Quantity(), c.Source)
+	for i, item := range c.Items {
+		name := item.Name
+		if item.Variant != "" {
+			name += " [" + item.Variant + "]"
+		}
+		fmt.Printf("%3d  %-50.50s %3d x %-12s %12s  %-9s %s\n", i+1, name, item.Quantity, item.Price, item.Subtotal(), item.Remaining(now), item.Shop)
+		if item.ItemID != 0 {
+			fmt.Printf("     %s\n", item.Key())
+		}
+	}
+
+	fmt.Println()
+	for _, shop := range c.ShopTotals() {
+		name := shop.Shop
+		if name == "" {
+			name = "(unknown shop)"
+		}
+		fmt.Printf("     %-50.50s %3d units    %12s\n", name, shop.Quantity, shop.Total)
+	}
+	fmt.Printf("     %-50s %3d units    %12s\n", "Total", c.Quantity(), c.Total())
+}
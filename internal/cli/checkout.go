@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/LLionNg/shopee-livestream-bot/internal/cart"
+	"github.com/LLionNg/shopee-livestream-bot/internal/checkout"
+	"github.com/LLionNg/shopee-livestream-bot/internal/config"
+	"github.com/LLionNg/shopee-livestream-bot/internal/notify"
+)
+
+func init() {
+	register(Command{
+		Name:    "checkout",
+		Usage:   "checkout <n|item-id>|orders",
+		Summary: "Check out one cart item with the checkout guards, or list recorded orders",
+		Run:     runCheckout,
+	})
+}
+
+func runCheckout(configPath string, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: bot checkout <n|item-id>|orders")
+	}
+
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return err
+	}
+	if args[0] == "orders" {
+		return listOrders(cfg)
+	}
+	if !cfg.Checkout.Enabled {
+		return fmt.Errorf("%w, set checkout.enabled in the config first", checkout.ErrDisabled)
+	}
+
+	sel, session, err := openLoggedIn(cfg)
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+	ctx := session.Context()
+
+	c, err := cart.NewManager(cfg, sel).List(ctx)
+	if err != nil {
+		return err
+	}
+	item, err := c.Find(args[0])
+	if err != nil {
+		return err
+	}
+
+	reservations, err := cart.NewTracker(cfg.Cart.ReservationsFile, nil, nil)
+	if err != nil {
+		return err
+	}
+	co, err := checkout.New(cfg, sel, nil, notify.New(cfg.Monitoring.Notifications), reservations)
+	if err != nil {
+		return err
+	}
+	order, err := co.Place(ctx, cart.Reservation{
+		ShopID: item.ShopID,
+		ItemID: item.ItemID,
+		Name:   item.Name,
+	})
+	if errors.Is(err, checkout.ErrOverBudget) || errors.Is(err, checkout.ErrNotApproved) {
+		fmt.Printf("🛑 %v\n", err)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("🧾 Order %s recorded in %s\n", order.ID, cfg.Checkout.OrdersFile)
+	return nil
+}
+
+// listOrders prints the orders the bot placed and the budget they used
+func listOrders(cfg *config.Config) error {
+	orders, err := checkout.LoadOrders(cfg.Checkout.OrdersFile)
+	if err != nil {
+		return err
+	}
+	if len(orders) == 0 {
+		fmt.Printf("No orders in %s\n", cfg.Checkout.OrdersFile)
+		return nil
+	}
+
+	since := time.Now().Add(-cfg.Checkout.GetBudgetPeriod())
+	var spent int64
+	for _, o := range orders {
+		id := o.ID
+		if id == "" {
+			id = "(unknown)"
+		}
+		fmt.Printf("%s  %-20s %-40.40s %3d  %12s\n", o.PlacedAt.Format("2006-01-02 15:04"), id, o.Name, o.Quantity, o.Total)
+		if !o.PlacedAt.Before(since) {
+			spent += o.Total.Amount
+		}
+	}
+
+	currency := cfg.Shopee.GetRegion().Currency
+	fmt.Printf("\n💰 Spent %s in the last %s (budget %.2f)\n", currency.Format(spent), cfg.Checkout.GetBudgetPeriod(), cfg.Checkout.Budget)
+	return nil
+}
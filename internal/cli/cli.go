@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Command is a bot subcommand such as "selectors test"
+type Command struct {
+	Name    string
+	Usage   string
+	Summary string
+	Run     func(configPath string, args []string) error
+}
+
+var commands = map[string]Command{}
+
+// register adds a subcommand to the dispatch table
+func register(c Command) {
+	commands[c.Name] = c
+}
+
+// Has reports whether name is a known subcommand
+func Has(name string) bool {
+	_, ok := commands[name]
+	return ok
+}
+
+// Run dispatches args to the matching subcommand
+func Run(configPath string, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("no command given\n%s", Usage())
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown command %q\n%s", args[0], Usage())
+	}
+	return cmd.Run(configPath, args[1:])
+}
+
+// Usage returns the help text listing every subcommand
+func Usage() string {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString("Usage: bot [-config path] [command]\n\nCommands:\n")
+	fmt.Fprintf(&b, "  %-32s %s\n", "run [--tui]", "Monitor livestreams (default); --tui shows a live dashboard")
+	for _, name := range names {
+		fmt.Fprintf(&b, "  %-32s %s\n", commands[name].Usage, commands[name].Summary)
+	}
+	return b.String()
+}
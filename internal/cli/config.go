@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/LLionNg/shopee-livestream-bot/internal/config"
+	"github.com/LLionNg/shopee-livestream-bot/internal/selectors"
+)
+
+func init() {
+	register(Command{
+		Name:    "validate-config",
+		Usage:   "validate-config",
+		Summary: "Check the config file and list every problem with its line",
+		Run:     runValidateConfig,
+	})
+}
+
+func runValidateConfig(configPath string, args []string) error {
+	cfg, err := config.Load(configPath)
+
+	var verr *config.ValidationError
+	if errors.As(err, &verr) {
+		for _, fe := range verr.Errors {
+			location := configPath
+			if fe.Line > 0 {
+				location = fmt.Sprintf("%s:%d", configPath, fe.Line)
+			}
+			fmt.Printf("❌ %s: %s: %s\n", location, fe.Path, fe.Msg)
+		}
+		return fmt.Errorf("%d problem(s) in %s", len(verr.Errors), configPath)
+	}
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return fmt.Errorf("config could not be loaded")
+	}
+
+	// The selector profile is part of the setup the bot won't start without
+	if _, err := selectors.LoadProfile(cfg.Shopee.SelectorsFile); err != nil {
+		fmt.Printf("❌ %s: shopee.selectors_file: %v\n", configPath, err)
+		return fmt.Errorf("selector profile could not be loaded")
+	}
+
+	fmt.Printf("✅ %s is valid (region %s, %d stream(s))\n", configPath, cfg.Shopee.Region, len(cfg.Shopee.LivestreamURLs))
+	return nil
+}
@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+
+	"github.com/LLionNg/shopee-livestream-bot/internal/browser"
+	"github.com/LLionNg/shopee-livestream-bot/internal/config"
+)
+
+func init() {
+	register(Command{
+		Name:    "doctor",
+		Usage:   "doctor",
+		Summary: "Report the Chrome binary, its version and DevTools compatibility",
+		Run:     runDoctor,
+	})
+}
+
+func runDoctor(configPath string, args []string) error {
+	fmt.Printf("🩺 System: %s/%s\n", runtime.GOOS, runtime.GOARCH)
+
+	// A broken config should not stop the browser checks
+	execPath := ""
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		fmt.Printf("⚠️  Config: %v\n", err)
+	} else {
+		execPath = cfg.Browser.ExecPath
+		fmt.Printf("✅ Config: %s\n", configPath)
+	}
+
+	if execPath != "" {
+		fmt.Printf("   browser.exec_path: %s\n", execPath)
+	}
+
+	chromePath, err := browser.FindChrome(execPath)
+	if err != nil {
+		fmt.Printf("❌ Chrome: %v\n", err)
+		return fmt.Errorf("chrome not found")
+	}
+	if chromePath == "" {
+		fmt.Println("⚠️  Chrome: no known install location found, relying on chromedp's default lookup")
+	} else {
+		fmt.Printf("✅ Chrome: %s\n", chromePath)
+		if version, err := browser.ChromeVersion(chromePath); err != nil {
+			fmt.Printf("⚠️  Version: could not run --version: %v\n", err)
+		} else {
+			fmt.Printf("   Version: %s\n", version)
+		}
+	}
+
+	fmt.Println("🔌 Launching headless Chrome to check the DevTools protocol...")
+	info, err := browser.Probe(context.Background(), chromePath)
+	if err != nil {
+		fmt.Printf("❌ Launch failed: %v\n", err)
+		return fmt.Errorf("chrome could not be launched")
+	}
+
+	fmt.Printf("   Product:  %s\n", info.Product)
+	fmt.Printf("   Protocol: %s\n", info.ProtocolVersion)
+	if err := info.Compatible(); err != nil {
+		fmt.Printf("❌ Incompatible: %v\n", err)
+		return fmt.Errorf("chrome is not compatible")
+	}
+
+	fmt.Println("✅ DevTools protocol is compatible")
+	return nil
+}
@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/LLionNg/shopee-livestream-bot/internal/browser"
+	"github.com/LLionNg/shopee-livestream-bot/internal/config"
+)
+
+func init() {
+	register(Command{
+		Name:    "profile",
+		Usage:   "profile list|reset <name>",
+		Summary: "List browser profiles or wipe one",
+		Run:     runProfile,
+	})
+}
+
+func runProfile(configPath string, args []string) error {
+	// Profiles can be managed even when the rest of the config is broken
+	dir := "data/browser"
+	current := ""
+	if cfg, err := config.Load(configPath); err != nil {
+		fmt.Printf("⚠️  Config: %v (using %s)\n", err, dir)
+	} else {
+		dir = cfg.Browser.ProfilesDir
+		current = cfg.Browser.Profile
+	}
+
+	switch {
+	case len(args) == 1 && args[0] == "list":
+		return listProfiles(dir, current)
+	case len(args) == 2 && args[0] == "reset":
+		if err := browser.ResetProfile(dir, args[1]); err != nil {
+			return err
+		}
+		fmt.Printf("🗑️  Profile %s wiped\n", args[1])
+		return nil
+	}
+	return fmt.Errorf("usage: bot profile list|reset <name>")
+}
+
+func listProfiles(dir, current string) error {
+	profiles, err := browser.ListProfiles(dir)
+	if err != nil {
+		return err
+	}
+	if len(profiles) == 0 {
+		fmt.Printf("No profiles in %s\n", dir)
+		return nil
+	}
+
+	fmt.Printf("📁 Profiles in %s\n", dir)
+	for _, p := range profiles {
+		marker := " "
+		if p.Name == current {
+			marker = "*"
+		}
+		fmt.Printf("%s %-20s %10s  %s  %s\n", marker, p.Name, formatSize(p.Size), p.ModTime.Format("2006-01-02 15:04"), p.Lock)
+	}
+	return nil
+}
+
+// formatSize renders a byte count in KB/MB/GB
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGT"[exp])
+}
@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/LLionNg/shopee-livestream-bot/internal/browser"
+	"github.com/LLionNg/shopee-livestream-bot/internal/config"
+	"github.com/LLionNg/shopee-livestream-bot/internal/selectors"
+)
+
+func init() {
+	register(Command{
+		Name:    "selectors",
+		Usage:   "selectors test <url|file>",
+		Summary: "Report which selectors match on a live or saved page",
+		Run:     runSelectors,
+	})
+}
+
+func runSelectors(configPath string, args []string) error {
+	if len(args) != 2 || args[0] != "test" {
+		return fmt.Errorf("usage: bot selectors test <url|file>")
+	}
+
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return err
+	}
+
+	store, err := selectors.NewStore(cfg.Shopee.SelectorsFile, cfg.Shopee.GetRegion().Texts)
+	if err != nil {
+		return err
+	}
+	profile := store.Profile()
+
+	target, err := pageURL(args[1])
+	if err != nil {
+		return err
+	}
+
+	session, err := browser.New(context.Background(), cfg, profile.UserAgent)
+	if err != nil {
+		if hint := BrowserHint(err); hint != "" {
+			return fmt.Errorf("%w (%s)", err, hint)
+		}
+		return err
+	}
+	defer session.Close()
+	browserCtx := session.Context()
+
+	if err := browser.NavigateWithRetry(browserCtx, target, 3); err != nil {
+		return err
+	}
+
+	region := profile.Region
+	if region == "" {
+		region = "any"
+	}
+	fmt.Printf("📋 Profile %s (version %s, region %s)\n", store.Path(), profile.Version, region)
+	fmt.Printf("🌐 Page: %s\n\n", target)
+
+	current := ""
+	matched := false
+	missing := 0
+	for _, r := range selectors.Test(browserCtx, profile) {
+		if r.Element != current {
+			if current != "" && !matched {
+				missing++
+			}
+			current = r.Element
+			matched = false
+			fmt.Printf("%s\n", r.Element)
+		}
+
+		switch {
+		case r.Err != nil:
+			fmt.Printf("   ⚠️  %-50s error: %v\n", r.Selector, r.Err)
+		case r.Matches > 0 && !matched:
+			matched = true
+			fmt.Printf("   ✅ %-50s %d match(es) - in use\n", r.Selector, r.Matches)
+		case r.Matches > 0:
+			fmt.Printf("   ✔️  %-50s %d match(es)\n", r.Selector, r.Matches)
+		default:
+			fmt.Printf("   ❌ %-50s no match\n", r.Selector)
+		}
+	}
+	if current != "" && !matched {
+		missing++
+	}
+
+	fmt.Printf("\n%d of %d elements have no matching selector on this page\n", missing, len(selectors.Elements))
+	return nil
+}
+
+// pageURL turns a saved HTML file into a file:// URL, leaving URLs untouched
+func pageURL(arg string) (string, error) {
+	if _, err := os.Stat(arg); err != nil {
+		return arg, nil
+	}
+
+	abs, err := filepath.Abs(arg)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.ToSlash(abs)
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path // Windows drive letters
+	}
+	return "file://" + path, nil
+}
@@ -0,0 +1,245 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/LLionNg/shopee-livestream-bot/internal/auth"
+	"github.com/LLionNg/shopee-livestream-bot/internal/browser"
+	"github.com/LLionNg/shopee-livestream-bot/internal/config"
+	"github.com/LLionNg/shopee-livestream-bot/internal/console"
+	"github.com/LLionNg/shopee-livestream-bot/internal/selectors"
+)
+
+func init() {
+	register(Command{
+		Name:    "check-session",
+		Usage:   "check-session",
+		Summary: "Show when the saved login session expires",
+		Run:     runCheckSession,
+	})
+	register(Command{
+		Name:    "session",
+		Usage:   "session export|import <file>",
+		Summary: "Move a logged-in session between machines (export -encrypt to encrypt)",
+		Run:     runSession,
+	})
+}
+
+func runCheckSession(configPath string, args []string) error {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return err
+	}
+
+	store, err := auth.OpenSessionStore(&cfg.Auth)
+	if err != nil {
+		return err
+	}
+	cookies, err := store.Load()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("📂 Session: %s (%d cookies)\n", store.Path(), len(cookies))
+
+	now := time.Now()
+	sort.Slice(cookies, func(i, j int) bool { return cookies[i].Name < cookies[j].Name })
+	for _, c := range cookies {
+		if !strings.HasPrefix(c.Name, "SPC_") {
+			continue
+		}
+		switch {
+		case c.Session || c.Expires <= 0:
+			fmt.Printf("   %-16s browser session\n", c.Name)
+		default:
+			at := time.Unix(int64(c.Expires), 0)
+			state := "in " + at.Sub(now).Round(time.Minute).String()
+			if !now.Before(at) {
+				state = "expired"
+			}
+			fmt.Printf("   %-16s %s (%s)\n", c.Name, at.Format(time.RFC1123), state)
+		}
+	}
+
+	exp := auth.SessionExpiry(cookies)
+	switch {
+	case !exp.Known():
+		fmt.Println("⚠️  No login cookie has an expiry date; the session may end when the browser closes")
+	case exp.Expired(now):
+		fmt.Printf("❌ Session expired %s (%s)\n", exp.At.Format(time.RFC1123), exp.Cookie)
+		return fmt.Errorf("session expired, log in again")
+	case exp.At.Sub(now) < cfg.Auth.GetExpiryWarning():
+		fmt.Printf("⚠️  Session expires %s, in %s (%s) - log in again soon\n", exp.At.Format(time.RFC1123), exp.At.Sub(now).Round(time.Minute), exp.Cookie)
+	default:
+		fmt.Printf("✅ Session valid until %s, in %s (%s)\n", exp.At.Format(time.RFC1123), exp.At.Sub(now).Round(time.Minute), exp.Cookie)
+	}
+	return nil
+}
+
+func runSession(configPath string, args []string) error {
+	usage := fmt.Errorf("usage: bot session export [-encrypt] <file>|import <file>")
+	if len(args) == 0 {
+		return usage
+	}
+
+	fs := flag.NewFlagSet("session "+args[0], flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	encrypt := fs.Bool("encrypt", false, "encrypt the bundle with a passphrase")
+	if err := fs.Parse(args[1:]); err != nil || fs.NArg() != 1 {
+		return usage
+	}
+
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "export":
+		return exportSession(cfg, fs.Arg(0), *encrypt)
+	case "import":
+		return importSession(cfg, fs.Arg(0))
+	}
+	return usage
+}
+
+// exportSession writes the saved session, with the origin's web storage,
+// to a bundle file
+func exportSession(cfg *config.Config, path string, encrypt bool) error {
+	// Ask before the browser starts
+	passphrase := ""
+	if encrypt {
+		var err error
+		if passphrase, err = bundlePassphrase(true); err != nil {
+			return err
+		}
+	}
+
+	m, session, err := openManager(cfg)
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	if err := m.LoadSession(session.Context()); err != nil {
+		return err
+	}
+	b, err := m.ExportBundle(session.Context())
+	if err != nil {
+		return err
+	}
+
+	if err := auth.WriteBundle(path, b, passphrase); err != nil {
+		return err
+	}
+
+	fmt.Printf("📦 Exported %d cookies, %d localStorage and %d sessionStorage entries to %s\n",
+		len(b.Cookies), len(b.LocalStorage), len(b.SessionStorage), path)
+	if encrypt {
+		fmt.Printf("🔒 Encrypted with your passphrase; enter it on import or set %s\n", auth.BundlePassphraseEnv)
+	} else {
+		fmt.Println("⚠️  The bundle holds your login in plain text - move it securely and delete it afterwards")
+	}
+	return nil
+}
+
+// importSession injects a bundle into the browser, validates the login and
+// saves it as this machine's session
+func importSession(cfg *config.Config, path string) error {
+	m, session, err := openManager(cfg)
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	b, err := auth.ReadBundle(path, func() (string, error) { return bundlePassphrase(false) })
+	if err != nil {
+		return err
+	}
+	fmt.Printf("📦 Bundle from %s exported %s\n", b.Origin, b.ExportedAt.Format(time.RFC1123))
+
+	userAgent, err := m.ImportBundle(session.Context(), b)
+	if err != nil {
+		return err
+	}
+	if b.UserAgent != "" && userAgent != b.UserAgent {
+		fmt.Println("⚠️  User agent differs from the exporting machine; Shopee may ask to verify the login")
+		fmt.Printf("   exported: %s\n   this:     %s\n", b.UserAgent, userAgent)
+	}
+
+	if !m.ValidateSession(session.Context()) {
+		return fmt.Errorf("imported session is not logged in")
+	}
+	if err := m.SaveSession(session.Context()); err != nil {
+		return err
+	}
+
+	fmt.Printf("✅ Session imported (%s) and saved to %s\n", m.User(), m.Store().Path())
+	if exp := m.Expiry(); exp.Known() {
+		fmt.Printf("📅 Expires %s\n", exp.At.Format(time.RFC1123))
+	}
+	return nil
+}
+
+// openManager starts a browser with an auth manager on its first tab
+func openManager(cfg *config.Config) (*auth.Manager, *browser.Session, error) {
+	sel, err := selectors.NewStore(cfg.Shopee.SelectorsFile, cfg.Shopee.GetRegion().Texts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	session, err := browser.New(context.Background(), cfg, sel.Profile().UserAgent)
+	if err != nil {
+		if hint := BrowserHint(err); hint != "" {
+			return nil, nil, fmt.Errorf("%w (%s)", err, hint)
+		}
+		return nil, nil, err
+	}
+
+	m, err := auth.NewManager(session.Context(), cfg, sel)
+	if err != nil {
+		session.Close()
+		return nil, nil, err
+	}
+	return m, session, nil
+}
+
+// bundlePassphrase reads the bundle passphrase from the environment or asks
+// for it, twice when confirm is set
+func bundlePassphrase(confirm bool) (string, error) {
+	if value, ok, err := config.LookupEnv(auth.BundlePassphraseEnv); err != nil || ok {
+		if err == nil && value == "" {
+			err = fmt.Errorf("%s is empty", auth.BundlePassphraseEnv)
+		}
+		return value, err
+	}
+	if !console.IsTerminal(os.Stdin) {
+		return "", fmt.Errorf("no bundle passphrase: set %s or run in a terminal", auth.BundlePassphraseEnv)
+	}
+
+	ctx := context.Background()
+	passphrase, err := console.PromptSecret(ctx, "🔑 Bundle passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if passphrase == "" {
+		return "", fmt.Errorf("no passphrase given")
+	}
+	if confirm {
+		again, err := console.PromptSecret(ctx, "🔑 Repeat passphrase: ")
+		if err != nil {
+			return "", err
+		}
+		if again != passphrase {
+			return "", fmt.Errorf("passphrases don't match")
+		}
+	}
+	return passphrase, nil
+}
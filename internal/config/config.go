@@ -1,12 +1,20 @@
 package config
 
 import (
+	"bytes"
 	"fmt"
 	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/LLionNg/shopee-livestream-bot/internal/region"
 	"github.com/joho/godotenv"
 	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
 )
 
 // Config holds all application configuration
@@ -14,24 +22,31 @@ type Config struct {
 	App        AppConfig        `mapstructure:"app"`
 	Shopee     ShopeeConfig     `mapstructure:"shopee"`
 	Browser    BrowserConfig    `mapstructure:"browser"`
+	Auth       AuthConfig       `mapstructure:"auth"`
 	Purchase   PurchaseConfig   `mapstructure:"purchase"`
+	Cart       CartConfig       `mapstructure:"cart"`
+	Checkout   CheckoutConfig   `mapstructure:"checkout"`
 	Proxy      ProxyConfig      `mapstructure:"proxy"`
 	Stealth    StealthConfig    `mapstructure:"stealth"`
 	Monitoring MonitoringConfig `mapstructure:"monitoring"`
+	Evidence   EvidenceConfig   `mapstructure:"evidence"`
 	Logging    LoggingConfig    `mapstructure:"logging"`
 }
 
 type AppConfig struct {
-	Name        string `mapstructure:"name"`
-	Version     string `mapstructure:"version"`
-	Environment string `mapstructure:"environment"`
+	Name            string `mapstructure:"name"`
+	Version         string `mapstructure:"version"`
+	Environment     string `mapstructure:"environment"`
+	ShutdownTimeout int    `mapstructure:"shutdown_timeout"`
 }
 
 type ShopeeConfig struct {
-	BaseURL        string              `mapstructure:"base_url"`
-	APIURL         string              `mapstructure:"api_url"`
-	LivestreamURLs []string            `mapstructure:"livestream_urls"`
-	Credentials    ShopeeCredentials   `mapstructure:"credentials"`
+	Region         string            `mapstructure:"region"`
+	BaseURL        string            `mapstructure:"base_url"`
+	APIURL         string            `mapstructure:"api_url"`
+	LivestreamURLs []string          `mapstructure:"livestream_urls"`
+	SelectorsFile  string            `mapstructure:"selectors_file"`
+	Credentials    ShopeeCredentials `mapstructure:"credentials"`
 }
 
 type ShopeeCredentials struct {
@@ -41,10 +56,42 @@ type ShopeeCredentials struct {
 }
 
 type BrowserConfig struct {
-	Headless    bool           `mapstructure:"headless"`
-	Timeout     int            `mapstructure:"timeout"`
-	UserDataDir string         `mapstructure:"user_data_dir"`
-	Viewport    ViewportConfig `mapstructure:"viewport"`
+	Headless     bool           `mapstructure:"headless"`
+	Timeout      int            `mapstructure:"timeout"`
+	ExecPath     string         `mapstructure:"exec_path"`
+	RemoteURL    string         `mapstructure:"remote_url"`
+	UserDataDir  string         `mapstructure:"user_data_dir"`
+	Profile      string         `mapstructure:"profile"`
+	ProfilesDir  string         `mapstructure:"profiles_dir"`
+	CloneProfile bool           `mapstructure:"clone_profile"`
+	Viewport     ViewportConfig `mapstructure:"viewport"`
+	Recovery     RecoveryConfig `mapstructure:"recovery"`
+}
+
+type RecoveryConfig struct {
+	Enabled     bool `mapstructure:"enabled"`
+	MaxRestarts int  `mapstructure:"max_restarts"`
+	Window      int  `mapstructure:"window"`
+}
+
+type AuthConfig struct {
+	SessionFile      string             `mapstructure:"session_file"`
+	KeyFile          string             `mapstructure:"key_file"`
+	ExpiryWarning    int                `mapstructure:"expiry_warning"`
+	ValidateInterval int                `mapstructure:"validate_interval"`
+	AccountEndpoint  string             `mapstructure:"account_endpoint"`
+	Verification     VerificationConfig `mapstructure:"verification"`
+}
+
+type VerificationConfig struct {
+	Sources  []string       `mapstructure:"sources"`
+	Timeout  int            `mapstructure:"timeout"`
+	Telegram TelegramConfig `mapstructure:"telegram"`
+}
+
+type TelegramConfig struct {
+	BotToken string `mapstructure:"bot_token"`
+	ChatID   string `mapstructure:"chat_id"`
 }
 
 type ViewportConfig struct {
@@ -53,8 +100,48 @@ type ViewportConfig struct {
 }
 
 type PurchaseConfig struct {
-	MaxRetries int `mapstructure:"max_retries"`
-	RetryDelay int `mapstructure:"retry_delay"`
+	MaxRetries  int               `mapstructure:"max_retries"`
+	RetryDelay  float64           `mapstructure:"retry_delay"`
+	Backoff     string            `mapstructure:"backoff"`
+	MaxDelay    float64           `mapstructure:"max_delay"`
+	MaxElapsed  float64           `mapstructure:"max_elapsed"`
+	Concurrency int               `mapstructure:"concurrency"`
+	Budget      float64           `mapstructure:"budget"`
+	QueueWait   int               `mapstructure:"queue_wait"`
+	Priorities  []ProductPriority `mapstructure:"priorities"`
+}
+
+// ProductPriority ranks products whose name contains Match, or whose item ID
+// equals it, when several streams want to buy at once
+type ProductPriority struct {
+	Match    string `mapstructure:"match"`
+	Priority int    `mapstructure:"priority"`
+}
+
+type CartConfig struct {
+	PageURL          string         `mapstructure:"page_url"`
+	GetEndpoint      string         `mapstructure:"get_endpoint"`
+	UpdateEndpoint   string         `mapstructure:"update_endpoint"`
+	Reservation      map[string]int `mapstructure:"reservation"`
+	Reminders        []int          `mapstructure:"reminders"`
+	ReservationsFile string         `mapstructure:"reservations_file"`
+}
+
+type CheckoutConfig struct {
+	Enabled      bool          `mapstructure:"enabled"`
+	Budget       float64       `mapstructure:"budget"`
+	BudgetPeriod int           `mapstructure:"budget_period"`
+	MaxOrder     float64       `mapstructure:"max_order"`
+	Address      string        `mapstructure:"address"`
+	Shipping     string        `mapstructure:"shipping"`
+	Payment      string        `mapstructure:"payment"`
+	Confirm      ConfirmConfig `mapstructure:"confirm"`
+	OrdersFile   string        `mapstructure:"orders_file"`
+}
+
+type ConfirmConfig struct {
+	Sources []string `mapstructure:"sources"`
+	Timeout int      `mapstructure:"timeout"`
 }
 
 type ProxyConfig struct {
@@ -68,10 +155,10 @@ type ProxyConfig struct {
 }
 
 type StealthConfig struct {
-	RandomizeFingerprint bool        `mapstructure:"randomize_fingerprint"`
-	RandomDelays         bool        `mapstructure:"random_delays"`
-	DelayRange           DelayRange  `mapstructure:"delay_range"`
-	UserAgentsFile       string      `mapstructure:"user_agents_file"`
+	RandomizeFingerprint bool       `mapstructure:"randomize_fingerprint"`
+	RandomDelays         bool       `mapstructure:"random_delays"`
+	DelayRange           DelayRange `mapstructure:"delay_range"`
+	UserAgentsFile       string     `mapstructure:"user_agents_file"`
 }
 
 type DelayRange struct {
@@ -80,9 +167,10 @@ type DelayRange struct {
 }
 
 type MonitoringConfig struct {
-	CheckInterval       int              `mapstructure:"check_interval"`
-	MaxConcurrentStreams int             `mapstructure:"max_concurrent_streams"`
-	Notifications       NotificationConfig `mapstructure:"notifications"`
+	CheckInterval        int                `mapstructure:"check_interval"`
+	MaxConcurrentStreams int                `mapstructure:"max_concurrent_streams"`
+	SnapshotsDir         string             `mapstructure:"snapshots_dir"`
+	Notifications        NotificationConfig `mapstructure:"notifications"`
 }
 
 type NotificationConfig struct {
@@ -90,6 +178,13 @@ type NotificationConfig struct {
 	WebhookURL string `mapstructure:"webhook_url"`
 }
 
+type EvidenceConfig struct {
+	Enabled    bool   `mapstructure:"enabled"`
+	Dir        string `mapstructure:"dir"`
+	MaxEntries int    `mapstructure:"max_entries"`
+	LogLines   int    `mapstructure:"log_lines"`
+}
+
 type LoggingConfig struct {
 	Level         string `mapstructure:"level"`
 	Format        string `mapstructure:"format"`
@@ -110,8 +205,27 @@ func Load(configPath string) (*Config, error) {
 	viper.SetConfigType("yaml")
 	viper.AutomaticEnv()
 
-	// Read config file
-	if err := viper.ReadInConfig(); err != nil {
+	// Read config file, keeping the document for key checks and the line
+	// numbers in errors
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	// Fill in ${VAR} references before anything reads the values; bad ones
+	// are reported with the other problems
+	refs := &problems{}
+	if len(doc.Content) > 0 {
+		interpolate(&doc, reflect.TypeOf(Config{}), "", refs)
+		if data, err = yaml.Marshal(&doc); err != nil {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+	}
+	if err := viper.ReadConfig(bytes.NewReader(data)); err != nil {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
@@ -121,46 +235,320 @@ func Load(configPath string) (*Config, error) {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
-	// Override with environment variables
-	cfg.Shopee.Credentials.Username = getEnv("SHOPEE_USERNAME", cfg.Shopee.Credentials.Username)
-	cfg.Shopee.Credentials.Password = getEnv("SHOPEE_PASSWORD", cfg.Shopee.Credentials.Password)
-	cfg.Shopee.Credentials.Phone = getEnv("SHOPEE_PHONE", cfg.Shopee.Credentials.Phone)
-
-	// Clear placeholder values if they weren't replaced
-	if cfg.Shopee.Credentials.Username == "${SHOPEE_USERNAME}" {
-		cfg.Shopee.Credentials.Username = ""
-	}
-	if cfg.Shopee.Credentials.Password == "${SHOPEE_PASSWORD}" {
-		cfg.Shopee.Credentials.Password = ""
-	}
-	if cfg.Shopee.Credentials.Phone == "${SHOPEE_PHONE}" {
-		cfg.Shopee.Credentials.Phone = ""
-	}
+	// Credentials and alert targets can be set from the environment alone
+	overrides(&cfg, refs)
 
 	// Validate configuration
-	if err := cfg.Validate(); err != nil {
+	if err := check(&doc, &cfg, refs.errs); err != nil {
 		return nil, fmt.Errorf("invalid configuration: %w", err)
 	}
 
 	return &cfg, nil
 }
 
-// Validate checks if configuration is valid
+// Validate checks every setting and fills in the defaults of those left
+// empty or zero. All problems are returned together as a *ValidationError.
 func (c *Config) Validate() error {
-	if c.Shopee.BaseURL == "" {
-		return fmt.Errorf("shopee.base_url is required")
+	p := &problems{}
+
+	if c.App.ShutdownTimeout < 0 {
+		p.notNegative("app.shutdown_timeout", float64(c.App.ShutdownTimeout))
+	} else if c.App.ShutdownTimeout == 0 {
+		c.App.ShutdownTimeout = 15
+	}
+
+	if c.Shopee.Region == "" {
+		c.Shopee.Region = "th"
+	}
+	r, err := region.Lookup(c.Shopee.Region)
+	if err != nil {
+		p.add("shopee.region", "%v", err)
+	} else {
+		if c.Shopee.BaseURL == "" {
+			c.Shopee.BaseURL = r.BaseURL()
+		}
+		if c.Shopee.APIURL == "" {
+			c.Shopee.APIURL = r.APIURL()
+		}
+		if c.Shopee.SelectorsFile == "" {
+			c.Shopee.SelectorsFile = r.SelectorsFile()
+		}
+		if !r.MatchesURL(c.Shopee.BaseURL) {
+			p.add("shopee.base_url", "%q does not belong to region %q (expected %s)", c.Shopee.BaseURL, r.Code, r.BaseURL())
+		}
+		if !r.MatchesURL(c.Shopee.APIURL) {
+			p.add("shopee.api_url", "%q does not belong to region %q (expected %s)", c.Shopee.APIURL, r.Code, r.APIURL())
+		}
 	}
 	if len(c.Shopee.LivestreamURLs) == 0 {
-		return fmt.Errorf("at least one livestream URL is required")
+		p.add("shopee.livestream_urls", "at least one livestream URL is required")
+	}
+	seen := map[string]int{}
+	for i, u := range c.Shopee.LivestreamURLs {
+		path := fmt.Sprintf("shopee.livestream_urls[%d]", i)
+		if first, ok := seen[u]; ok {
+			p.add(path, "same stream as livestream_urls[%d]", first)
+			continue
+		}
+		seen[u] = i
+		p.url(path, u, "http", "https")
 	}
 	// Credentials are optional - manual login will be used if not provided
-	if c.Browser.Timeout <= 0 {
+
+	if c.Browser.RemoteURL != "" {
+		p.url("browser.remote_url", c.Browser.RemoteURL, "ws", "wss", "http", "https")
+		// An attached browser is already running with its own binary and profile
+		if c.Browser.ExecPath != "" {
+			p.add("browser.exec_path", "cannot be combined with browser.remote_url")
+		}
+		if c.Browser.UserDataDir != "" {
+			p.add("browser.user_data_dir", "cannot be combined with browser.remote_url")
+		}
+		if c.Browser.CloneProfile {
+			p.add("browser.clone_profile", "cannot be combined with browser.remote_url")
+		}
+	}
+	if c.Browser.Profile != "" {
+		if c.Browser.UserDataDir != "" {
+			p.add("browser.profile", "browser.profile and browser.user_data_dir cannot both be set")
+		}
+		if c.Browser.Profile != filepath.Base(c.Browser.Profile) || strings.HasPrefix(c.Browser.Profile, ".") {
+			p.add("browser.profile", "%q must be a plain name, not a path", c.Browser.Profile)
+		}
+	}
+	if c.Browser.ProfilesDir == "" {
+		c.Browser.ProfilesDir = "data/browser"
+	}
+	p.notNegative("browser.timeout", float64(c.Browser.Timeout))
+	if c.Browser.Timeout == 0 {
 		c.Browser.Timeout = 30
 	}
-	if c.Purchase.MaxRetries <= 0 {
+	p.notNegative("browser.viewport.width", float64(c.Browser.Viewport.Width))
+	p.notNegative("browser.viewport.height", float64(c.Browser.Viewport.Height))
+	p.notNegative("browser.recovery.max_restarts", float64(c.Browser.Recovery.MaxRestarts))
+	if c.Browser.Recovery.MaxRestarts == 0 {
+		c.Browser.Recovery.MaxRestarts = 3
+	}
+	p.notNegative("browser.recovery.window", float64(c.Browser.Recovery.Window))
+	if c.Browser.Recovery.Window == 0 {
+		c.Browser.Recovery.Window = 600
+	}
+
+	if c.Auth.SessionFile == "" {
+		c.Auth.SessionFile = "data/cookies/session.json"
+	}
+	if c.Auth.KeyFile == "" {
+		c.Auth.KeyFile = DefaultKeyFile()
+	}
+	p.notNegative("auth.expiry_warning", float64(c.Auth.ExpiryWarning))
+	if c.Auth.ExpiryWarning == 0 {
+		c.Auth.ExpiryWarning = 24
+	}
+	p.notNegative("auth.validate_interval", float64(c.Auth.ValidateInterval))
+	if c.Auth.ValidateInterval == 0 {
+		c.Auth.ValidateInterval = 10
+	}
+	if c.Auth.AccountEndpoint == "" {
+		c.Auth.AccountEndpoint = "/account/basic/get_account_info"
+	}
+	if strings.Contains(c.Auth.AccountEndpoint, "://") {
+		p.url("auth.account_endpoint", c.Auth.AccountEndpoint, "http", "https")
+	}
+	if c.Auth.Verification.Sources == nil {
+		c.Auth.Verification.Sources = []string{"terminal"}
+	}
+	c.checkSources(p, "auth.verification.sources", c.Auth.Verification.Sources)
+	p.notNegative("auth.verification.timeout", float64(c.Auth.Verification.Timeout))
+	if c.Auth.Verification.Timeout == 0 {
+		c.Auth.Verification.Timeout = 300
+	}
+
+	p.notNegative("purchase.max_retries", float64(c.Purchase.MaxRetries))
+	if c.Purchase.MaxRetries == 0 {
 		c.Purchase.MaxRetries = 3
 	}
-	return nil
+	p.notNegative("purchase.retry_delay", c.Purchase.RetryDelay)
+	p.notNegative("purchase.max_delay", c.Purchase.MaxDelay)
+	p.notNegative("purchase.max_elapsed", c.Purchase.MaxElapsed)
+	if c.Purchase.MaxDelay > 0 && c.Purchase.MaxDelay < c.Purchase.RetryDelay {
+		p.add("purchase.max_delay", "%v is less than purchase.retry_delay %v", c.Purchase.MaxDelay, c.Purchase.RetryDelay)
+	}
+	if c.Purchase.Backoff == "" {
+		c.Purchase.Backoff = "linear"
+	}
+	p.oneOf("purchase.backoff", c.Purchase.Backoff, "constant", "linear", "exponential", "exponential-jitter")
+	p.notNegative("purchase.concurrency", float64(c.Purchase.Concurrency))
+	if c.Purchase.Concurrency == 0 {
+		c.Purchase.Concurrency = 1
+	}
+	p.notNegative("purchase.budget", c.Purchase.Budget)
+	p.notNegative("purchase.queue_wait", float64(c.Purchase.QueueWait))
+	if c.Purchase.QueueWait == 0 {
+		c.Purchase.QueueWait = 30
+	}
+	for i, pr := range c.Purchase.Priorities {
+		if strings.TrimSpace(pr.Match) == "" {
+			p.add(fmt.Sprintf("purchase.priorities[%d].match", i), "match is required")
+		}
+	}
+
+	if c.Cart.PageURL == "" {
+		c.Cart.PageURL = strings.TrimRight(c.Shopee.BaseURL, "/") + "/cart"
+	} else {
+		p.url("cart.page_url", c.Cart.PageURL, "http", "https")
+	}
+	if c.Cart.GetEndpoint == "" {
+		c.Cart.GetEndpoint = "/cart/get"
+	}
+	if c.Cart.UpdateEndpoint == "" {
+		c.Cart.UpdateEndpoint = "/cart/update"
+	}
+	for _, e := range []struct{ path, value string }{
+		{"cart.get_endpoint", c.Cart.GetEndpoint},
+		{"cart.update_endpoint", c.Cart.UpdateEndpoint},
+	} {
+		if strings.Contains(e.value, "://") {
+			p.url(e.path, e.value, "http", "https")
+		}
+	}
+	for key, minutes := range c.Cart.Reservation {
+		if minutes <= 0 {
+			p.add("cart.reservation."+key, "must be a positive number of minutes, got %d", minutes)
+		}
+	}
+	if c.Cart.Reminders == nil {
+		c.Cart.Reminders = []int{10, 5, 2}
+	}
+	for i, minutes := range c.Cart.Reminders {
+		if minutes <= 0 {
+			p.add(fmt.Sprintf("cart.reminders[%d]", i), "must be positive minutes, got %d", minutes)
+		}
+	}
+	if c.Cart.ReservationsFile == "" {
+		c.Cart.ReservationsFile = "data/cart/reservations.json"
+	}
+
+	p.notNegative("checkout.budget", c.Checkout.Budget)
+	p.notNegative("checkout.max_order", c.Checkout.MaxOrder)
+	if c.Checkout.Enabled {
+		if c.Checkout.Budget == 0 {
+			p.add("checkout.budget", "is required when checkout is enabled")
+		}
+		if c.Checkout.Payment == "" {
+			p.add("checkout.payment", "must name the payment method when checkout is enabled")
+		}
+	}
+	if c.Checkout.Budget > 0 && c.Checkout.MaxOrder > c.Checkout.Budget {
+		p.add("checkout.max_order", "%v is more than checkout.budget %v, so no such order could be placed", c.Checkout.MaxOrder, c.Checkout.Budget)
+	}
+	p.notNegative("checkout.budget_period", float64(c.Checkout.BudgetPeriod))
+	if c.Checkout.BudgetPeriod == 0 {
+		c.Checkout.BudgetPeriod = 24
+	}
+	if c.Checkout.Confirm.Sources == nil {
+		c.Checkout.Confirm.Sources = []string{"terminal"}
+	}
+	c.checkSources(p, "checkout.confirm.sources", c.Checkout.Confirm.Sources)
+	p.notNegative("checkout.confirm.timeout", float64(c.Checkout.Confirm.Timeout))
+	if c.Checkout.Confirm.Timeout == 0 {
+		c.Checkout.Confirm.Timeout = 60
+	}
+	if c.Checkout.OrdersFile == "" {
+		c.Checkout.OrdersFile = "data/checkout/orders.json"
+	}
+
+	if c.Proxy.Type != "" {
+		p.oneOf("proxy.type", c.Proxy.Type, "residential", "datacenter")
+	}
+	p.notNegative("proxy.rotation_interval", float64(c.Proxy.RotationInterval))
+	if c.Proxy.Enabled && c.Proxy.Rotate && c.Proxy.RotationInterval == 0 {
+		p.add("proxy.rotation_interval", "is required when proxy.rotate is on")
+	}
+	p.notNegative("proxy.health_check_interval", float64(c.Proxy.HealthCheckInterval))
+
+	p.notNegative("stealth.delay_range.min", float64(c.Stealth.DelayRange.Min))
+	p.notNegative("stealth.delay_range.max", float64(c.Stealth.DelayRange.Max))
+	if c.Stealth.DelayRange.Min > c.Stealth.DelayRange.Max {
+		p.add("stealth.delay_range", "min %d is greater than max %d", c.Stealth.DelayRange.Min, c.Stealth.DelayRange.Max)
+	}
+
+	p.notNegative("monitoring.check_interval", float64(c.Monitoring.CheckInterval))
+	if c.Monitoring.CheckInterval == 0 {
+		c.Monitoring.CheckInterval = 1
+	}
+	p.notNegative("monitoring.max_concurrent_streams", float64(c.Monitoring.MaxConcurrentStreams))
+	if limit := c.Monitoring.MaxConcurrentStreams; limit > 0 && len(c.Shopee.LivestreamURLs) > limit {
+		p.add("shopee.livestream_urls", "%d streams configured, more than monitoring.max_concurrent_streams (%d)", len(c.Shopee.LivestreamURLs), limit)
+	}
+	if c.Monitoring.SnapshotsDir == "" {
+		c.Monitoring.SnapshotsDir = "data/snapshots"
+	}
+	if c.Monitoring.Notifications.WebhookURL != "" {
+		p.url("monitoring.notifications.webhook_url", c.Monitoring.Notifications.WebhookURL, "http", "https")
+	}
+
+	if c.Evidence.Dir == "" {
+		c.Evidence.Dir = "data/evidence"
+	}
+	p.notNegative("evidence.max_entries", float64(c.Evidence.MaxEntries))
+	if c.Evidence.MaxEntries == 0 {
+		c.Evidence.MaxEntries = 100
+	}
+	p.notNegative("evidence.log_lines", float64(c.Evidence.LogLines))
+	if c.Evidence.LogLines == 0 {
+		c.Evidence.LogLines = 200
+	}
+
+	if c.Logging.Level != "" {
+		p.oneOf("logging.level", c.Logging.Level, "debug", "info", "warn", "error")
+	}
+	if c.Logging.Format != "" {
+		p.oneOf("logging.format", c.Logging.Format, "json", "text")
+	}
+	p.notNegative("logging.max_size", float64(c.Logging.MaxSize))
+	p.notNegative("logging.max_backups", float64(c.Logging.MaxBackups))
+	p.notNegative("logging.max_age", float64(c.Logging.MaxAge))
+
+	return p.err()
+}
+
+// DefaultKeyFile is where the session key is kept unless auth.key_file says
+// otherwise: the user's config directory, away from the session file under
+// data/. It is empty when the system has no such directory.
+func DefaultKeyFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "shopee-livestream-bot", "session.key")
+}
+
+// checkSources checks the answer sources of a prompt: terminal and/or
+// telegram, which needs the bot token and chat ID
+func (c *Config) checkSources(p *problems, path string, sources []string) {
+	for i, source := range sources {
+		switch source {
+		case "terminal":
+		case "telegram":
+			if c.Auth.Verification.Telegram.BotToken == "" || c.Auth.Verification.Telegram.ChatID == "" {
+				p.add(path, "source telegram needs TELEGRAM_BOT_TOKEN and TELEGRAM_CHAT_ID")
+			}
+		default:
+			p.add(fmt.Sprintf("%s[%d]", path, i), "unknown source %q (use terminal or telegram)", source)
+		}
+	}
+}
+
+// GetRegion returns the configured Shopee region.
+// Validate must have succeeded, so the lookup cannot fail.
+func (c *ShopeeConfig) GetRegion() region.Region {
+	r, _ := region.Lookup(c.Region)
+	return r
+}
+
+// LoginURL returns the region's login page URL
+func (c *ShopeeConfig) LoginURL() string {
+	return c.BaseURL + c.GetRegion().LoginPath
 }
 
 // GetTimeout returns browser timeout as duration
@@ -168,20 +556,146 @@ func (c *BrowserConfig) GetTimeout() time.Duration {
 	return time.Duration(c.Timeout) * time.Second
 }
 
+// ProfileDir returns the Chrome user data directory to use: user_data_dir
+// when set, otherwise the named profile under profiles_dir ("" for none)
+func (c *BrowserConfig) ProfileDir() string {
+	if c.UserDataDir != "" {
+		return c.UserDataDir
+	}
+	if c.Profile != "" {
+		return filepath.Join(c.ProfilesDir, c.Profile)
+	}
+	return ""
+}
+
+// GetExpiryWarning returns how long before session expiry to warn
+func (c *AuthConfig) GetExpiryWarning() time.Duration {
+	return time.Duration(c.ExpiryWarning) * time.Hour
+}
+
+// AccountURL returns the "who am I" endpoint: account_endpoint itself when
+// it is a full URL, otherwise account_endpoint under apiURL
+func (c *AuthConfig) AccountURL(apiURL string) string {
+	return endpointURL(apiURL, c.AccountEndpoint)
+}
+
+// GetURL returns the cart listing endpoint, resolved like AccountURL
+func (c *CartConfig) GetURL(apiURL string) string {
+	return endpointURL(apiURL, c.GetEndpoint)
+}
+
+// UpdateURL returns the cart update endpoint, resolved like AccountURL
+func (c *CartConfig) UpdateURL(apiURL string) string {
+	return endpointURL(apiURL, c.UpdateEndpoint)
+}
+
+// ReservationFor returns how long the given region holds an item added to
+// the cart during a livestream: the region's entry, else "default", else
+// 15 minutes
+func (c *CartConfig) ReservationFor(region string) time.Duration {
+	minutes, ok := c.Reservation[region]
+	if !ok {
+		minutes, ok = c.Reservation["default"]
+	}
+	if !ok {
+		minutes = 15
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// GetReminders returns how long before a reservation ends to send each
+// reminder, earliest first
+func (c *CartConfig) GetReminders() []time.Duration {
+	reminders := make([]time.Duration, 0, len(c.Reminders))
+	for _, minutes := range c.Reminders {
+		reminders = append(reminders, time.Duration(minutes)*time.Minute)
+	}
+	sort.Slice(reminders, func(i, j int) bool { return reminders[i] > reminders[j] })
+	return reminders
+}
+
+// GetBudgetPeriod returns the window the checkout budget covers
+func (c *CheckoutConfig) GetBudgetPeriod() time.Duration {
+	return time.Duration(c.BudgetPeriod) * time.Hour
+}
+
+// GetTimeout returns how long to wait for an order to be approved
+func (c *ConfirmConfig) GetTimeout() time.Duration {
+	return time.Duration(c.Timeout) * time.Second
+}
+
+// endpointURL resolves an endpoint that is either a full URL or a path
+// under apiURL
+func endpointURL(apiURL, endpoint string) string {
+	if strings.Contains(endpoint, "://") {
+		return endpoint
+	}
+	return strings.TrimRight(apiURL, "/") + "/" + strings.TrimLeft(endpoint, "/")
+}
+
+// GetValidateInterval returns how often the session is re-validated
+func (c *AuthConfig) GetValidateInterval() time.Duration {
+	return time.Duration(c.ValidateInterval) * time.Minute
+}
+
+// GetTimeout returns how long to wait for a verification answer
+func (c *VerificationConfig) GetTimeout() time.Duration {
+	return time.Duration(c.Timeout) * time.Second
+}
+
+// GetWindow returns the browser restart window as duration
+func (c *RecoveryConfig) GetWindow() time.Duration {
+	return time.Duration(c.Window) * time.Second
+}
+
+// GetShutdownTimeout returns how long shutdown waits for running work
+func (c *AppConfig) GetShutdownTimeout() time.Duration {
+	return time.Duration(c.ShutdownTimeout) * time.Second
+}
+
 // GetRetryDelay returns retry delay as duration
 func (c *PurchaseConfig) GetRetryDelay() time.Duration {
-	return time.Duration(c.RetryDelay) * time.Second
+	return seconds(c.RetryDelay)
+}
+
+// GetMaxDelay returns the longest wait between attempts, 0 for no cap
+func (c *PurchaseConfig) GetMaxDelay() time.Duration {
+	return seconds(c.MaxDelay)
+}
+
+// GetMaxElapsed returns how long to keep retrying, 0 for no limit
+func (c *PurchaseConfig) GetMaxElapsed() time.Duration {
+	return seconds(c.MaxElapsed)
+}
+
+// seconds converts a possibly fractional number of seconds to a duration
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// GetQueueWait returns how long a purchase may wait for its turn
+func (c *PurchaseConfig) GetQueueWait() time.Duration {
+	return time.Duration(c.QueueWait) * time.Second
+}
+
+// PriorityFor returns the priority of a product: the highest of the rules it
+// matches, or 0
+func (c *PurchaseConfig) PriorityFor(name string, itemID int64) int {
+	name = strings.ToLower(name)
+	id := strconv.FormatInt(itemID, 10)
+	best, matched := 0, false
+	for _, p := range c.Priorities {
+		match := strings.ToLower(strings.TrimSpace(p.Match))
+		if (itemID != 0 && match == id) || (name != "" && strings.Contains(name, match)) {
+			if !matched || p.Priority > best {
+				best, matched = p.Priority, true
+			}
+		}
+	}
+	return best
 }
 
 // GetCheckInterval returns monitoring check interval as duration
 func (c *MonitoringConfig) GetCheckInterval() time.Duration {
 	return time.Duration(c.CheckInterval) * time.Second
 }
-
-// getEnv gets environment variable with fallback
-func getEnv(key, fallback string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
-	}
-	return fallback
-}
\ No newline at end of file
@@ -0,0 +1,203 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LookupEnv returns the environment variable name or, when it isn't set,
+// the contents of the file named by name_FILE (e.g. a Docker secret) without
+// the trailing newline. ok is false when neither is set.
+func LookupEnv(name string) (value string, ok bool, err error) {
+	if value, ok := os.LookupEnv(name); ok {
+		return value, true, nil
+	}
+	path, ok := os.LookupEnv(name + "_FILE")
+	if !ok || path == "" {
+		return "", false, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, fmt.Errorf("%s_FILE: %w", name, err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), true, nil
+}
+
+// overrides applies the variables that set a value whatever the config file
+// says, as they did before ${VAR} references existed. Unset or empty ones
+// leave the file's value.
+func overrides(cfg *Config, p *problems) {
+	for _, o := range []struct {
+		name, path string
+		value      *string
+	}{
+		{"SHOPEE_USERNAME", "shopee.credentials.username", &cfg.Shopee.Credentials.Username},
+		{"SHOPEE_PASSWORD", "shopee.credentials.password", &cfg.Shopee.Credentials.Password},
+		{"SHOPEE_PHONE", "shopee.credentials.phone", &cfg.Shopee.Credentials.Phone},
+		{"WEBHOOK_URL", "monitoring.notifications.webhook_url", &cfg.Monitoring.Notifications.WebhookURL},
+		{"TELEGRAM_BOT_TOKEN", "auth.verification.telegram.bot_token", &cfg.Auth.Verification.Telegram.BotToken},
+		{"TELEGRAM_CHAT_ID", "auth.verification.telegram.chat_id", &cfg.Auth.Verification.Telegram.ChatID},
+	} {
+		value, _, err := LookupEnv(o.name)
+		if err != nil {
+			p.add(o.path, "%v", err)
+			continue
+		}
+		if value != "" {
+			*o.value = value
+		}
+	}
+}
+
+// interpolate replaces ${VAR} references in every value of the document,
+// reporting bad references and required variables that aren't set. t is the
+// type the node decodes into, nil when no field takes it.
+func interpolate(node *yaml.Node, t reflect.Type, path string, p *problems) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, n := range node.Content {
+			interpolate(n, t, path, p)
+		}
+	case yaml.SequenceNode:
+		for i, n := range node.Content {
+			interpolate(n, fieldType(t, ""), path+"["+strconv.Itoa(i)+"]", p)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := strings.ToLower(node.Content[i].Value)
+			interpolate(node.Content[i+1], fieldType(t, key), joinPath(path, key), p)
+		}
+	case yaml.ScalarNode:
+		if !strings.Contains(node.Value, "$") {
+			return
+		}
+		value, err := expand(node.Value)
+		if err != nil {
+			p.errs = append(p.errs, FieldError{Path: path, Line: node.Line, Msg: err.Error()})
+			return
+		}
+		if value != node.Value && node.Style == 0 && t != nil && t.Kind() != reflect.String {
+			// An unquoted value for a non-string field takes the type of
+			// what it expands to, so "timeout: ${TIMEOUT:-30}" is a number.
+			// String fields keep the value as it is, e.g. a chat ID -00123.
+			node.Tag = ""
+		}
+		node.Value = value
+	}
+}
+
+// fieldType returns the type of t's element, or of its field named key
+// when t is a struct; nil when there is none
+func fieldType(t reflect.Type, key string) reflect.Type {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil {
+		return nil
+	}
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return t.Elem()
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if t.Field(i).Tag.Get("mapstructure") == key {
+				return t.Field(i).Type
+			}
+		}
+	}
+	return nil
+}
+
+// expand replaces the references in s:
+//
+//	${VAR}          the variable, empty when unset
+//	${VAR:-default} the variable, or default when unset or empty
+//	${VAR:?message} the variable; an error with message when unset or empty
+//	$${             a literal "${"
+//
+// VAR_FILE stands in for an unset VAR, see LookupEnv.
+func expand(s string) (string, error) {
+	var b strings.Builder
+	for {
+		i := strings.Index(s, "${")
+		if i < 0 {
+			b.WriteString(s)
+			return b.String(), nil
+		}
+		if i > 0 && s[i-1] == '$' {
+			b.WriteString(s[:i-1] + "${")
+			s = s[i+2:]
+			continue
+		}
+		b.WriteString(s[:i])
+
+		end := strings.IndexByte(s[i:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated reference %q", s[i:])
+		}
+		ref := s[i+2 : i+end]
+		s = s[i+end+1:]
+
+		value, err := resolve(ref)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(value)
+	}
+}
+
+// resolve returns the value of one reference, without the ${ and }
+func resolve(ref string) (string, error) {
+	name, op, arg := ref, "", ""
+	if i := strings.IndexByte(ref, ':'); i >= 0 {
+		name, op, arg = ref[:i], ref[i:min(i+2, len(ref))], ref[min(i+2, len(ref)):]
+	}
+	if !validName(name) {
+		return "", fmt.Errorf("bad reference ${%s}: %q is not a variable name", ref, name)
+	}
+
+	value, _, err := LookupEnv(name)
+	if err != nil {
+		return "", err
+	}
+	switch op {
+	case "":
+		return value, nil
+	case ":-":
+		if value == "" {
+			return arg, nil
+		}
+		return value, nil
+	case ":?":
+		if value == "" {
+			if arg == "" {
+				arg = "is required"
+			}
+			return "", fmt.Errorf("%s %s", name, arg)
+		}
+		return value, nil
+	default:
+		return "", fmt.Errorf("bad reference ${%s}: use ${%s}, ${%s:-default} or ${%s:?message}", ref, name, name, name)
+	}
+}
+
+// validName reports whether name is an environment variable name
+func validName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r == '_', r >= 'A' && r <= 'Z', r >= 'a' && r <= 'z':
+		case r >= '0' && r <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,148 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExpand(t *testing.T) {
+	t.Setenv("BOT_USER", "alice")
+	t.Setenv("BOT_EMPTY", "")
+	secret := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(secret, []byte("s3cret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("BOT_TOKEN_FILE", secret)
+
+	tests := []struct {
+		in, want string
+	}{
+		{"${BOT_USER}", "alice"},
+		{"user=${BOT_USER}, again ${BOT_USER}", "user=alice, again alice"},
+		{"${BOT_UNSET}", ""},
+		{"${BOT_UNSET:-fallback}", "fallback"},
+		{"${BOT_EMPTY:-fallback}", "fallback"},
+		{"${BOT_USER:-fallback}", "alice"},
+		{"${BOT_TOKEN}", "s3cret"},
+		{"$${BOT_USER} costs $5", "${BOT_USER} costs $5"},
+	}
+	for _, tt := range tests {
+		got, err := expand(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("expand(%q) = %q, %v; want %q", tt.in, got, err, tt.want)
+		}
+	}
+
+	for _, in := range []string{"${BOT_UNSET:?set it}", "${BOT_EMPTY:?}", "${BOT-USER}", "${BOT_USER", "${BOT_USER:=x}"} {
+		if got, err := expand(in); err == nil {
+			t.Errorf("expand(%q) = %q, want an error", in, got)
+		}
+	}
+}
+
+func TestLoadInterpolates(t *testing.T) {
+	t.Setenv("BOT_STREAM", "https://th.shp.ee/abc")
+	t.Setenv("BOT_INTERVAL", "3")
+	t.Setenv("BOT_HEADLESS", "true")
+	path := writeConfig(t, `shopee:
+  livestream_urls: ["${BOT_STREAM}"]
+  credentials:
+    username: "${BOT_UNSET_USER}"
+browser:
+  headless: ${BOT_HEADLESS}
+  timeout: ${BOT_UNSET_TIMEOUT:-45}
+monitoring:
+  check_interval: ${BOT_INTERVAL}
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Shopee.LivestreamURLs[0] != "https://th.shp.ee/abc" || cfg.Shopee.Credentials.Username != "" {
+		t.Errorf("strings not expanded: %q, %q", cfg.Shopee.LivestreamURLs[0], cfg.Shopee.Credentials.Username)
+	}
+	if !cfg.Browser.Headless || cfg.Browser.Timeout != 45 || cfg.Monitoring.CheckInterval != 3 {
+		t.Errorf("typed values not expanded: headless %v, timeout %d, check_interval %d",
+			cfg.Browser.Headless, cfg.Browser.Timeout, cfg.Monitoring.CheckInterval)
+	}
+}
+
+func TestLoadInterpolatesStringFields(t *testing.T) {
+	t.Setenv("BOT_CHAT_ID", "-00123")
+	t.Setenv("BOT_TOKEN", "0x1F")
+	t.Setenv("BOT_USER", "true")
+	path := writeConfig(t, `shopee:
+  livestream_urls: ["https://th.shp.ee/abc"]
+  credentials:
+    username: ${BOT_USER}
+auth:
+  verification:
+    telegram:
+      bot_token: ${BOT_TOKEN}
+      chat_id: ${BOT_CHAT_ID}
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	tg := cfg.Auth.Verification.Telegram
+	if tg.ChatID != "-00123" || tg.BotToken != "0x1F" || cfg.Shopee.Credentials.Username != "true" {
+		t.Errorf("string fields re-typed: chat_id %q, bot_token %q, username %q",
+			tg.ChatID, tg.BotToken, cfg.Shopee.Credentials.Username)
+	}
+}
+
+func TestLoadRequiredVariable(t *testing.T) {
+	path := writeConfig(t, `shopee:
+  livestream_urls: ["https://th.shp.ee/abc"]
+monitoring:
+  notifications:
+    webhook_url: "${BOT_UNSET_WEBHOOK:?must point at the alert channel}"
+`)
+
+	_, err := Load(path)
+	var verr *ValidationError
+	if !errors.As(err, &verr) || len(verr.Errors) != 1 {
+		t.Fatalf("Load error = %v, want one problem", err)
+	}
+	want := FieldError{Path: "monitoring.notifications.webhook_url", Line: 5, Msg: "BOT_UNSET_WEBHOOK must point at the alert channel"}
+	if verr.Errors[0] != want {
+		t.Errorf("problem = %+v, want %+v", verr.Errors[0], want)
+	}
+}
+
+func TestLoadOverrides(t *testing.T) {
+	t.Setenv("SHOPEE_USERNAME", "alice")
+	t.Setenv("SHOPEE_PASSWORD", "")
+	secret := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(secret, []byte("123:abc\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TELEGRAM_BOT_TOKEN_FILE", secret)
+	path := writeConfig(t, `shopee:
+  livestream_urls: ["https://th.shp.ee/abc"]
+  credentials:
+    username: "bob"
+    password: "from-file"
+auth:
+  verification:
+    telegram:
+      bot_token: "old"
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	creds := cfg.Shopee.Credentials
+	if creds.Username != "alice" || creds.Password != "from-file" {
+		t.Errorf("credentials = %q, %q; want the variable over the file, the file without one", creds.Username, creds.Password)
+	}
+	if got := cfg.Auth.Verification.Telegram.BotToken; got != "123:abc" {
+		t.Errorf("bot token = %q, want it from TELEGRAM_BOT_TOKEN_FILE", got)
+	}
+}
@@ -0,0 +1,262 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FieldError is a problem with one setting
+type FieldError struct {
+	Path string // e.g. "monitoring.check_interval" or "shopee.livestream_urls[1]"
+	Line int    // line in the config file, 0 when unknown
+	Msg  string
+}
+
+func (e FieldError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("line %d: %s: %s", e.Line, e.Path, e.Msg)
+	}
+	return fmt.Sprintf("%s: %s", e.Path, e.Msg)
+}
+
+// ValidationError lists every problem found in a config, in file order
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		msgs[i] = fe.Error()
+	}
+	if len(msgs) == 1 {
+		return msgs[0]
+	}
+	return fmt.Sprintf("%d problems: %s", len(msgs), strings.Join(msgs, "; "))
+}
+
+// check validates cfg, read from doc, and also reports keys in doc that
+// aren't settings. The problems, including those found earlier, carry the
+// line they were found on.
+func check(doc *yaml.Node, cfg *Config, found []FieldError) error {
+	p := &problems{errs: found}
+	checkKeys(doc, reflect.TypeOf(cfg), "", p)
+
+	// A setting whose reference failed is only reported once
+	reported := map[string]bool{}
+	for _, fe := range found {
+		reported[fe.Path] = true
+	}
+	var verr *ValidationError
+	if err := cfg.Validate(); errors.As(err, &verr) {
+		for _, fe := range verr.Errors {
+			if !reported[fe.Path] {
+				p.errs = append(p.errs, fe)
+			}
+		}
+	} else if err != nil {
+		return err
+	}
+	if len(p.errs) == 0 {
+		return nil
+	}
+
+	lines := map[string]int{}
+	lineIndex(doc, "", lines)
+	verr = &ValidationError{Errors: p.errs}
+	verr.locate(lines)
+	return verr
+}
+
+// locate fills in the line of each problem from the file's index of
+// settings. A missing setting points at the closest section that exists.
+func (e *ValidationError) locate(lines map[string]int) {
+	for i := range e.Errors {
+		for path := e.Errors[i].Path; path != ""; path = parentPath(path) {
+			if line, ok := lines[path]; ok {
+				e.Errors[i].Line = line
+				break
+			}
+		}
+	}
+	sort.SliceStable(e.Errors, func(i, j int) bool {
+		a, b := e.Errors[i].Line, e.Errors[j].Line
+		return a != 0 && (b == 0 || a < b)
+	})
+}
+
+// parentPath drops the last element of a setting path
+func parentPath(path string) string {
+	if i := strings.LastIndexAny(path, ".["); i > 0 {
+		return path[:i]
+	}
+	return ""
+}
+
+// problems collects validation errors
+type problems struct {
+	errs []FieldError
+}
+
+func (p *problems) add(path, format string, args ...interface{}) {
+	p.errs = append(p.errs, FieldError{Path: path, Msg: fmt.Sprintf(format, args...)})
+}
+
+// err returns the collected problems, or nil without any
+func (p *problems) err() error {
+	if len(p.errs) == 0 {
+		return nil
+	}
+	return &ValidationError{Errors: p.errs}
+}
+
+// notNegative reports a negative number; zero means the default
+func (p *problems) notNegative(path string, v float64) {
+	if v < 0 {
+		p.add(path, "must not be negative, got %v", v)
+	}
+}
+
+// url reports a value that isn't an absolute URL with one of schemes
+func (p *problems) url(path, value string, schemes ...string) {
+	u, err := url.Parse(value)
+	if err != nil || u.Host == "" {
+		p.add(path, "%q is not a valid URL", value)
+		return
+	}
+	for _, s := range schemes {
+		if u.Scheme == s {
+			return
+		}
+	}
+	p.add(path, "must use %s://, got %q", strings.Join(schemes, "://, "), u.Scheme)
+}
+
+// oneOf reports a value that isn't one of allowed
+func (p *problems) oneOf(path, value string, allowed ...string) {
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	p.add(path, "unknown value %q (use %s)", value, strings.Join(allowed, ", "))
+}
+
+// checkKeys reports keys in node that don't match a field of t, suggesting
+// the field that was probably meant
+func checkKeys(node *yaml.Node, t reflect.Type, path string, p *problems) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, n := range node.Content {
+			checkKeys(n, t, path, p)
+		}
+
+	case yaml.SequenceNode:
+		if t.Kind() != reflect.Slice {
+			return
+		}
+		for i, n := range node.Content {
+			checkKeys(n, t.Elem(), fmt.Sprintf("%s[%d]", path, i), p)
+		}
+
+	case yaml.MappingNode:
+		switch t.Kind() {
+		case reflect.Map:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				checkKeys(node.Content[i+1], t.Elem(), joinPath(path, node.Content[i].Value), p)
+			}
+		case reflect.Struct:
+			fields := map[string]reflect.Type{}
+			var names []string
+			for i := 0; i < t.NumField(); i++ {
+				name := t.Field(i).Tag.Get("mapstructure")
+				fields[name] = t.Field(i).Type
+				names = append(names, name)
+			}
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				key := node.Content[i]
+				keyPath := joinPath(path, key.Value)
+				ft, ok := fields[strings.ToLower(key.Value)]
+				if !ok {
+					p.errs = append(p.errs, FieldError{Path: keyPath, Line: key.Line, Msg: unknownKey(key.Value, names)})
+					continue
+				}
+				checkKeys(node.Content[i+1], ft, keyPath, p)
+			}
+		}
+	}
+}
+
+// unknownKey describes an unknown key, naming the closest known one
+func unknownKey(key string, known []string) string {
+	best, bestDist := "", 3
+	for _, k := range known {
+		if d := distance(strings.ToLower(key), k); d < bestDist {
+			best, bestDist = k, d
+		}
+	}
+	if best != "" {
+		return fmt.Sprintf("unknown key (did you mean %s?)", best)
+	}
+	return "unknown key"
+}
+
+// distance is the edit distance between a and b
+func distance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// lineIndex maps the path of every setting in node to its line
+func lineIndex(node *yaml.Node, path string, lines map[string]int) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, n := range node.Content {
+			lineIndex(n, path, lines)
+		}
+	case yaml.SequenceNode:
+		for i, n := range node.Content {
+			itemPath := path + "[" + strconv.Itoa(i) + "]"
+			lines[itemPath] = n.Line
+			lineIndex(n, itemPath, lines)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			keyPath := joinPath(path, strings.ToLower(node.Content[i].Value))
+			lines[keyPath] = node.Content[i].Line
+			lineIndex(node.Content[i+1], keyPath, lines)
+		}
+	}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
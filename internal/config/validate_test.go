@@ -0,0 +1,101 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeConfig(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadReportsEveryProblem(t *testing.T) {
+	path := writeConfig(t, `shopee:
+  region: "th"
+  livestream_urls:
+    - "https://th.shp.ee/abc"
+    - "th.shp.ee/def"
+browser:
+  remote_url: "http://127.0.0.1:9222"
+  exec_path: "/usr/bin/chromium"
+purchase:
+  retry_delay: -1
+  backoff: "fast"
+stealth:
+  delay_range:
+    min: 900
+    max: 500
+monitoring:
+  check_intervall: 0
+`)
+
+	_, err := Load(path)
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Load error = %v, want a ValidationError", err)
+	}
+
+	want := []FieldError{
+		{Path: "shopee.livestream_urls[1]", Line: 5},
+		{Path: "browser.exec_path", Line: 8},
+		{Path: "purchase.retry_delay", Line: 10},
+		{Path: "purchase.backoff", Line: 11},
+		{Path: "stealth.delay_range", Line: 13},
+		{Path: "monitoring.check_intervall", Line: 17, Msg: "unknown key (did you mean check_interval?)"},
+	}
+	if len(verr.Errors) != len(want) {
+		t.Fatalf("got %d problems, want %d:\n%v", len(verr.Errors), len(want), verr)
+	}
+	for i, w := range want {
+		got := verr.Errors[i]
+		if got.Path != w.Path || got.Line != w.Line || (w.Msg != "" && got.Msg != w.Msg) {
+			t.Errorf("problem %d = %+v, want %+v", i, got, w)
+		}
+	}
+}
+
+func TestLoadValid(t *testing.T) {
+	path := writeConfig(t, `shopee:
+  livestream_urls: ["https://th.shp.ee/abc"]
+cart:
+  reservation:
+    th: 20
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Monitoring.CheckInterval != 1 || cfg.Purchase.MaxRetries != 3 || cfg.Browser.Timeout != 30 {
+		t.Errorf("defaults not applied: check_interval %d, max_retries %d, timeout %d",
+			cfg.Monitoring.CheckInterval, cfg.Purchase.MaxRetries, cfg.Browser.Timeout)
+	}
+}
+
+func TestValidateMissingSettingPointsAtSection(t *testing.T) {
+	cfg := &Config{
+		Shopee:   ShopeeConfig{LivestreamURLs: []string{"https://th.shp.ee/abc"}},
+		Checkout: CheckoutConfig{Enabled: true, Budget: 100, MaxOrder: 200},
+	}
+	err := cfg.Validate()
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Validate error = %v, want a ValidationError", err)
+	}
+
+	verr.locate(map[string]int{"checkout": 7, "checkout.max_order": 9})
+	got := verr.Error()
+	for _, want := range []string{"line 7: checkout.payment:", "line 9: checkout.max_order: 200 is more than checkout.budget 100"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("%q lacks %q", got, want)
+		}
+	}
+}
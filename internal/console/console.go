@@ -0,0 +1,155 @@
+// Package console is how the bot's runtime packages talk to the terminal.
+// Every line goes through one output: plain text on a terminal, structured
+// logs when stdout isn't one, or the log pane of the dashboard.
+package console
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// Line is one line of bot output
+type Line struct {
+	Time   time.Time
+	Level  slog.Level
+	Stream int // 0 when the line isn't about a stream
+	Text   string
+}
+
+// Output receives the bot's output lines
+type Output interface {
+	Write(l Line)
+}
+
+var (
+	mu  sync.Mutex
+	out Output = Plain(os.Stdout)
+)
+
+// SetOutput sends all further output to o
+func SetOutput(o Output) {
+	mu.Lock()
+	defer mu.Unlock()
+	out = o
+}
+
+// Printf writes one line of output. Lines are written whole, so output from
+// several goroutines doesn't interleave.
+func Printf(format string, args ...interface{}) {
+	emit(fmt.Sprintf(format, args...))
+}
+
+// Println writes its arguments as one line of output
+func Println(args ...interface{}) {
+	emit(fmt.Sprintln(args...))
+}
+
+func emit(text string) {
+	mu.Lock()
+	o := out
+	mu.Unlock()
+	o.Write(parse(strings.TrimRight(text, "\n")))
+}
+
+var streamPattern = regexp.MustCompile(`\[Stream (\d+)\]`)
+
+// parse reads the level from the line's leading symbol (or the logger's
+// level field) and the stream from its "[Stream n]" tag
+func parse(text string) Line {
+	l := Line{Time: time.Now(), Level: slog.LevelInfo, Text: text}
+	trimmed := strings.TrimSpace(text)
+	switch {
+	case strings.HasPrefix(trimmed, "❌"), strings.HasPrefix(trimmed, "💥"), strings.HasPrefix(trimmed, "🚨"),
+		strings.Contains(text, "level=ERROR"):
+		l.Level = slog.LevelError
+	case strings.HasPrefix(trimmed, "⚠️"), strings.HasPrefix(trimmed, "🛑"),
+		strings.Contains(text, "level=WARN"):
+		l.Level = slog.LevelWarn
+	}
+	if m := streamPattern.FindStringSubmatch(text); m != nil {
+		l.Stream, _ = strconv.Atoi(m[1])
+	}
+	return l
+}
+
+// plain writes lines as they are
+type plain struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// Plain returns an output writing lines to w unchanged
+func Plain(w io.Writer) Output {
+	return &plain{w: w}
+}
+
+func (p *plain) Write(l Line) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fmt.Fprintln(p.w, l.Text)
+}
+
+// structured logs lines through slog
+type structured struct {
+	log *slog.Logger
+}
+
+// Structured returns an output logging each line as a record with its level
+// and stream, without the decoration
+func Structured(log *slog.Logger) Output {
+	return &structured{log: log}
+}
+
+func (s *structured) Write(l Line) {
+	msg := strings.TrimSpace(streamPattern.ReplaceAllString(l.Text, ""))
+	msg = strings.TrimLeftFunc(msg, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune(`["'(`, r)
+	})
+	var attrs []slog.Attr
+	if l.Stream > 0 {
+		attrs = append(attrs, slog.Int("stream", l.Stream))
+	}
+	s.log.LogAttrs(context.Background(), l.Level, msg, attrs...)
+}
+
+// lineWriter turns written bytes into output lines
+type lineWriter struct {
+	mu      sync.Mutex
+	partial []byte
+}
+
+// Writer returns an io.Writer whose lines become output lines, e.g. for a
+// logger that should show up on the dashboard
+func Writer() io.Writer {
+	return &lineWriter{}
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.partial = append(w.partial, p...)
+	for {
+		i := strings.IndexByte(string(w.partial), '\n')
+		if i < 0 {
+			break
+		}
+		emit(string(w.partial[:i]))
+		w.partial = w.partial[i+1:]
+	}
+	return len(p), nil
+}
+
+// IsTerminal reports whether f is a terminal
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
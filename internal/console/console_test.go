@@ -0,0 +1,142 @@
+package console
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// record collects output lines
+type record struct{ lines []Line }
+
+func (r *record) Write(l Line) { r.lines = append(r.lines, l) }
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		text   string
+		level  slog.Level
+		stream int
+	}{
+		{"✅ [Stream 2] Successfully loaded livestream", slog.LevelInfo, 2},
+		{"❌ [Stream 12] Purchase failed: sold out", slog.LevelError, 12},
+		{"⚠️  [Stream 1] Check error: timeout", slog.LevelWarn, 1},
+		{"🛑 Stopping", slog.LevelWarn, 0},
+		{`time=2024-01-01T00:00:00Z level=ERROR msg="Monitor stopped"`, slog.LevelError, 0},
+		{"Starting livestream monitoring...", slog.LevelInfo, 0},
+	}
+	for _, tt := range tests {
+		l := parse(tt.text)
+		if l.Level != tt.level || l.Stream != tt.stream || l.Text != tt.text {
+			t.Errorf("parse(%q) = level %v stream %d, want %v %d", tt.text, l.Level, l.Stream, tt.level, tt.stream)
+		}
+	}
+}
+
+func TestStructured(t *testing.T) {
+	var buf bytes.Buffer
+	o := Structured(slog.New(slog.NewTextHandler(&buf, nil)))
+	o.Write(parse("❌ [Stream 3] Purchase failed: sold out"))
+
+	got := buf.String()
+	for _, want := range []string{"level=ERROR", `msg="Purchase failed: sold out"`, "stream=3"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("structured line %q lacks %q", got, want)
+		}
+	}
+}
+
+func TestWriterAndPrintf(t *testing.T) {
+	r := &record{}
+	SetOutput(r)
+	defer SetOutput(Plain(&bytes.Buffer{}))
+
+	w := Writer()
+	fmt.Fprint(w, "first line\nsecond ")
+	fmt.Fprint(w, "line\n")
+	Printf("[Stream %d] third\n", 4)
+	Println("fourth", 5)
+
+	var texts []string
+	for _, l := range r.lines {
+		texts = append(texts, l.Text)
+	}
+	want := []string{"first line", "second line", "[Stream 4] third", "fourth 5"}
+	if !reflect.DeepEqual(texts, want) {
+		t.Errorf("lines = %q, want %q", texts, want)
+	}
+	if r.lines[2].Stream != 4 {
+		t.Errorf("stream = %d, want 4", r.lines[2].Stream)
+	}
+}
+
+func TestRoute(t *testing.T) {
+	// Set the handler directly; setKeys would start reading the real stdin
+	var keys []byte
+	input.mu.Lock()
+	input.keys = func(b byte) { keys = append(keys, b) }
+	input.mu.Unlock()
+	defer func() {
+		input.mu.Lock()
+		input.keys = nil
+		input.mu.Unlock()
+	}()
+
+	// Keys go to the dashboard while nobody is waiting for a line
+	route([]byte("pb"))
+	if string(keys) != "pb" {
+		t.Errorf("keys = %q, want %q", keys, "pb")
+	}
+
+	// Lines typed with nobody asking are dropped
+	input.mu.Lock()
+	input.keys = nil
+	input.mu.Unlock()
+	route([]byte("yes\n"))
+
+	// A waiting prompt gets whole lines instead
+	ch := make(chan string, 1)
+	input.mu.Lock()
+	input.prompts = append(input.prompts, ch)
+	input.mu.Unlock()
+	route([]byte("12"))
+	route([]byte("34\n"))
+	if got := <-ch; got != "1234" {
+		t.Errorf("prompt got %q, want %q", got, "1234")
+	}
+	if string(keys) != "pb" {
+		t.Errorf("keys while prompting = %q", keys)
+	}
+}
+
+func TestPack(t *testing.T) {
+	got := pack([]string{"Session: ok", "Budget left: ฿100", "Purchases: running"}, "   ", 35)
+	want := []string{"Session: ok   Budget left: ฿100", "Purchases: running"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("pack = %q, want %q", got, want)
+	}
+	if got := truncate("abcdef", 4); got != "abc…" {
+		t.Errorf("truncate = %q", got)
+	}
+	if got := pad("ab", 4); got != "ab  " {
+		t.Errorf("pad = %q", got)
+	}
+}
+
+func TestNextStream(t *testing.T) {
+	streams := []StreamStatus{{ID: 1}, {ID: 2}, {ID: 3}}
+	if got := nextStream(streams, 2, 1); got != 3 {
+		t.Errorf("next of 2 = %d", got)
+	}
+	if got := nextStream(streams, 3, 1); got != 3 {
+		t.Errorf("next of last = %d", got)
+	}
+	if got := nextStream(streams, 1, -1); got != 1 {
+		t.Errorf("previous of first = %d", got)
+	}
+	if got := nextStream(streams, 9, 1); got != 1 {
+		t.Errorf("next of unknown = %d", got)
+	}
+}
@@ -0,0 +1,531 @@
+package console
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StreamStatus is what the dashboard shows for one stream
+type StreamStatus struct {
+	ID        int
+	State     string
+	Product   string
+	Price     string
+	Stock     string
+	Countdown string
+	Paused    bool
+	Updated   time.Time
+}
+
+// PurchaseEvent is a purchase attempt in the dashboard's recent list
+type PurchaseEvent struct {
+	Time     time.Time
+	StreamID int
+	Name     string
+	Price    string
+	Manual   bool
+	Err      error
+}
+
+const (
+	maxPurchases = 8
+	maxLogLines  = 500
+)
+
+// status is kept up to date by the runtime packages whether or not a
+// dashboard shows it
+var status struct {
+	mu        sync.Mutex
+	streams   map[int]*StreamStatus
+	purchases []PurchaseEvent
+}
+
+// UpdateStream changes what the dashboard shows for a stream
+func UpdateStream(id int, update func(s *StreamStatus)) {
+	status.mu.Lock()
+	defer status.mu.Unlock()
+	if status.streams == nil {
+		status.streams = map[int]*StreamStatus{}
+	}
+	s, ok := status.streams[id]
+	if !ok {
+		s = &StreamStatus{ID: id}
+		status.streams[id] = s
+	}
+	update(s)
+	s.Updated = time.Now()
+}
+
+// RecordPurchase adds a purchase attempt to the recent list
+func RecordPurchase(p PurchaseEvent) {
+	status.mu.Lock()
+	defer status.mu.Unlock()
+	if p.Time.IsZero() {
+		p.Time = time.Now()
+	}
+	status.purchases = append(status.purchases, p)
+	if len(status.purchases) > maxPurchases {
+		status.purchases = status.purchases[len(status.purchases)-maxPurchases:]
+	}
+}
+
+// snapshot copies the status for drawing
+func snapshot() ([]StreamStatus, []PurchaseEvent) {
+	status.mu.Lock()
+	defer status.mu.Unlock()
+	streams := make([]StreamStatus, 0, len(status.streams))
+	for _, s := range status.streams {
+		streams = append(streams, *s)
+	}
+	sort.Slice(streams, func(i, j int) bool { return streams[i].ID < streams[j].ID })
+	return streams, append([]PurchaseEvent(nil), status.purchases...)
+}
+
+// Controls are the actions behind the dashboard's keys
+type Controls interface {
+	// TogglePause stops or restarts automatic purchases on a stream
+	TogglePause(stream int) (paused bool, err error)
+	// BuyNow asks a stream to buy the product it shows right away
+	BuyNow(stream int) error
+}
+
+// Terminal control sequences
+const (
+	enterAlt   = "\x1b[?1049h\x1b[?25l\x1b[?7l"
+	leaveAlt   = "\x1b[?7h\x1b[?25h\x1b[?1049l"
+	home       = "\x1b[H"
+	clearLine  = "\x1b[K"
+	clearBelow = "\x1b[J"
+	bold       = "\x1b[1m"
+	dim        = "\x1b[2m"
+	reverse    = "\x1b[7m"
+	red        = "\x1b[31m"
+	green      = "\x1b[32m"
+	yellow     = "\x1b[33m"
+	reset      = "\x1b[0m"
+)
+
+var (
+	activeMu sync.Mutex
+	active   *Dashboard
+)
+
+// suspend hands the terminal back for a prompt, returning the func that
+// brings the dashboard back
+func suspend() (resume func()) {
+	activeMu.Lock()
+	d := active
+	activeMu.Unlock()
+	if d == nil {
+		return func() {}
+	}
+	d.suspend()
+	return d.resume
+}
+
+// infoLine is a label and its current value in the dashboard header
+type infoLine struct {
+	label string
+	value func() string
+}
+
+// section is an extra list shown below the recent purchases
+type section struct {
+	title string
+	lines func() []string
+}
+
+// Dashboard is the full-screen terminal view of `bot run --tui`. It is an
+// Output: log lines go to its log pane.
+type Dashboard struct {
+	title    string
+	controls Controls
+	quit     func()
+	info     []infoLine
+	sections []section
+
+	mu        sync.Mutex
+	logs      []Line
+	selected  int
+	message   string
+	suspended int
+	fd        int
+	raw       *termState
+	keys      chan byte
+}
+
+// NewDashboard creates a dashboard. quit is called when the operator
+// presses q.
+func NewDashboard(title string, controls Controls, quit func()) *Dashboard {
+	return &Dashboard{
+		title:    title,
+		controls: controls,
+		quit:     quit,
+		selected: 1,
+		fd:       int(os.Stdin.Fd()),
+		keys:     make(chan byte, 64),
+	}
+}
+
+// AddInfo adds a value shown in the header, e.g. the remaining budget
+func (d *Dashboard) AddInfo(label string, value func() string) {
+	d.info = append(d.info, infoLine{label, value})
+}
+
+// AddSection adds a list shown below the recent purchases
+func (d *Dashboard) AddSection(title string, lines func() []string) {
+	d.sections = append(d.sections, section{title, lines})
+}
+
+// Write implements Output
+func (d *Dashboard) Write(l Line) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.logs = append(d.logs, l)
+	if len(d.logs) > maxLogLines {
+		d.logs = d.logs[len(d.logs)-maxLogLines:]
+	}
+}
+
+// Run shows the dashboard until ctx is done and then gives the terminal
+// back as it was
+func (d *Dashboard) Run(ctx context.Context) {
+	d.mu.Lock()
+	raw, err := makeRaw(d.fd)
+	if err != nil {
+		d.message = fmt.Sprintf("keys disabled: %v", err)
+	}
+	d.raw = raw
+	fmt.Print(enterAlt)
+	d.mu.Unlock()
+
+	activeMu.Lock()
+	active = d
+	activeMu.Unlock()
+	if raw != nil {
+		setKeys(func(b byte) {
+			select {
+			case d.keys <- b:
+			default:
+			}
+		})
+	}
+
+	defer func() {
+		setKeys(nil)
+		activeMu.Lock()
+		active = nil
+		activeMu.Unlock()
+
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		if d.raw != nil {
+			restore(d.fd, d.raw)
+		}
+		fmt.Print(leaveAlt)
+	}()
+
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+	var esc []byte
+	d.draw()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case b := <-d.keys:
+			// Arrow keys arrive as ESC [ A/B
+			switch {
+			case b == 0x1b:
+				esc = []byte{b}
+				continue
+			case len(esc) == 1 && b == '[':
+				esc = append(esc, b)
+				continue
+			case len(esc) == 2:
+				esc = nil
+				switch b {
+				case 'A':
+					b = 'k'
+				case 'B':
+					b = 'j'
+				default:
+					continue
+				}
+			}
+			d.handleKey(b)
+		}
+		d.draw()
+	}
+}
+
+// handleKey runs the action bound to key b
+func (d *Dashboard) handleKey(b byte) {
+	streams, _ := snapshot()
+
+	d.mu.Lock()
+	selected := d.selected
+	d.mu.Unlock()
+
+	msg := ""
+	switch {
+	case b >= '1' && b <= '9':
+		selected = int(b - '0')
+	case b == 'j':
+		selected = nextStream(streams, selected, 1)
+	case b == 'k':
+		selected = nextStream(streams, selected, -1)
+	case b == 'p':
+		if paused, err := d.controls.TogglePause(selected); err != nil {
+			msg = err.Error()
+		} else if paused {
+			msg = fmt.Sprintf("stream %d paused: no automatic purchases", selected)
+		} else {
+			msg = fmt.Sprintf("stream %d resumed", selected)
+		}
+	case b == 'b':
+		if err := d.controls.BuyNow(selected); err != nil {
+			msg = err.Error()
+		} else {
+			msg = fmt.Sprintf("stream %d: buying now", selected)
+		}
+	case b == 'q':
+		msg = "stopping..."
+		d.quit()
+	default:
+		return
+	}
+
+	d.mu.Lock()
+	d.selected = selected
+	d.message = msg
+	d.mu.Unlock()
+}
+
+// nextStream returns the stream after (dir 1) or before (dir -1) id
+func nextStream(streams []StreamStatus, id, dir int) int {
+	for i, s := range streams {
+		if s.ID != id {
+			continue
+		}
+		if j := i + dir; j >= 0 && j < len(streams) {
+			return streams[j].ID
+		}
+		return id
+	}
+	if len(streams) > 0 {
+		return streams[0].ID
+	}
+	return id
+}
+
+// suspend leaves the full-screen view so the terminal can be used normally
+func (d *Dashboard) suspend() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.suspended++; d.suspended > 1 {
+		return
+	}
+	if d.raw != nil {
+		restore(d.fd, d.raw)
+	}
+	fmt.Print(leaveAlt)
+
+	// Show what led to the prompt
+	from := len(d.logs) - 5
+	if from < 0 {
+		from = 0
+	}
+	for _, l := range d.logs[from:] {
+		fmt.Println(l.Text)
+	}
+}
+
+// resume brings the full-screen view back
+func (d *Dashboard) resume() {
+	d.mu.Lock()
+	if d.suspended--; d.suspended > 0 {
+		d.mu.Unlock()
+		return
+	}
+	if d.raw != nil {
+		makeRaw(d.fd)
+	}
+	fmt.Print(enterAlt)
+	d.mu.Unlock()
+	d.draw()
+}
+
+// draw renders the whole screen
+func (d *Dashboard) draw() {
+	streams, purchases := snapshot()
+
+	width, height, err := termSize(int(os.Stdout.Fd()))
+	if err != nil || width <= 0 || height <= 0 {
+		width, height = 100, 30
+	}
+
+	var top []string
+	add := func(s string) { top = append(top, s) }
+
+	add(bold + pad(d.title, width-8) + reset + time.Now().Format("15:04:05"))
+	var parts []string
+	for _, info := range d.info {
+		parts = append(parts, info.label+": "+info.value())
+	}
+	for _, l := range pack(parts, "   ", width) {
+		add(l)
+	}
+	add("")
+
+	productWidth := width - 60
+	if productWidth < 12 {
+		productWidth = 12
+	}
+	add(dim + fmt.Sprintf("  %-3s %-18s %s %-12s %-10s %-10s", "#", "STATE", pad("PRODUCT", productWidth), "PRICE", "STOCK", "COUNTDOWN") + reset)
+	d.mu.Lock()
+	selected := d.selected
+	d.mu.Unlock()
+	for _, s := range streams {
+		state := s.State
+		color := ""
+		switch {
+		case s.Paused:
+			state, color = "paused ("+state+")", yellow
+		case state == "watching":
+			color = green
+		case strings.HasPrefix(state, "waiting"), strings.HasPrefix(state, "error"):
+			color = red
+		}
+		row := fmt.Sprintf("  %-3d %s%-18s%s %s %-12s %-10s %-10s", s.ID, color, truncate(state, 18), reset,
+			pad(s.Product, productWidth), truncate(s.Price, 12), truncate(s.Stock, 10), truncate(s.Countdown, 10))
+		if s.ID == selected {
+			row = reverse + ">" + strings.ReplaceAll(row[1:], reset, reset+reverse) + reset
+		}
+		add(row)
+	}
+	if len(streams) == 0 {
+		add(dim + "  no streams yet" + reset)
+	}
+
+	add("")
+	add(bold + "Recent purchases" + reset)
+	if len(purchases) == 0 {
+		add(dim + "  none yet" + reset)
+	}
+	for i := len(purchases) - 1; i >= 0; i-- {
+		p := purchases[i]
+		result := green + "added" + reset
+		if p.Err != nil {
+			result = red + truncate(p.Err.Error(), width/2) + reset
+		}
+		manual := ""
+		if p.Manual {
+			manual = " (manual)"
+		}
+		add(fmt.Sprintf("  %s  [%d] %s %s%s  %s", p.Time.Format("15:04:05"), p.StreamID, truncate(p.Name, 40), p.Price, manual, result))
+	}
+	for _, sec := range d.sections {
+		add("")
+		add(bold + sec.title + reset)
+		lines := sec.lines()
+		if len(lines) == 0 {
+			add(dim + "  none" + reset)
+		}
+		for _, l := range lines {
+			add("  " + l)
+		}
+	}
+	add("")
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.suspended > 0 {
+		return
+	}
+
+	// The log pane gets whatever height is left
+	footer := []string{
+		yellow + truncate(d.message, width) + reset,
+		dim + "1-9/↑↓ select stream   p pause/resume   b buy now   q quit" + reset,
+	}
+	logRows := height - len(top) - len(footer) - 1
+	var b strings.Builder
+	b.WriteString(home)
+	for _, l := range top {
+		b.WriteString(l + clearLine + "\n")
+	}
+	if logRows > 0 {
+		b.WriteString(bold + "Log" + reset + clearLine + "\n")
+		from := len(d.logs) - logRows + 1
+		if from < 0 {
+			from = 0
+		}
+		for _, l := range d.logs[from:] {
+			text := l.Time.Format("15:04:05") + " " + truncate(l.Text, width-10)
+			switch l.Level {
+			case slog.LevelError:
+				text = red + text + reset
+			case slog.LevelWarn:
+				text = yellow + text + reset
+			}
+			b.WriteString(text + clearLine + "\n")
+		}
+	}
+	b.WriteString(clearBelow)
+	fmt.Fprint(os.Stdout, b.String())
+	fmt.Fprintf(os.Stdout, "\x1b[%d;1H%s%s\n%s%s", height-1, footer[0], clearLine, footer[1], clearLine)
+}
+
+// truncate shortens s to at most n characters
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if n <= 0 {
+		return ""
+	}
+	if len(r) <= n {
+		return s
+	}
+	if n == 1 {
+		return "…"
+	}
+	return string(r[:n-1]) + "…"
+}
+
+// pad truncates or pads s to exactly n characters
+func pad(s string, n int) string {
+	s = truncate(s, n)
+	if gap := n - len([]rune(s)); gap > 0 {
+		s += strings.Repeat(" ", gap)
+	}
+	return s
+}
+
+// pack joins parts into as few lines of at most width characters as it can
+func pack(parts []string, sep string, width int) []string {
+	var lines []string
+	cur := ""
+	for _, p := range parts {
+		switch {
+		case cur == "":
+			cur = p
+		case len([]rune(cur+sep+p)) <= width:
+			cur += sep + p
+		default:
+			lines = append(lines, truncate(cur, width))
+			cur = p
+		}
+	}
+	if cur != "" {
+		lines = append(lines, truncate(cur, width))
+	}
+	return lines
+}
@@ -0,0 +1,137 @@
+package console
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// input owns standard input: one reader for the whole process, handing
+// lines to prompts and, while the dashboard runs, keys to the dashboard
+var input struct {
+	once    sync.Once
+	mu      sync.Mutex
+	prompts []chan string // waiting prompts, first come first answered
+	partial []byte
+	keys    func(b byte) // the dashboard's key handler, nil without one
+	closed  bool
+}
+
+func startInput() {
+	input.once.Do(func() {
+		go func() {
+			buf := make([]byte, 256)
+			for {
+				n, err := os.Stdin.Read(buf)
+				if n > 0 {
+					route(buf[:n])
+				}
+				if err != nil {
+					input.mu.Lock()
+					input.closed = true
+					for _, p := range input.prompts {
+						close(p)
+					}
+					input.prompts = nil
+					input.mu.Unlock()
+					return
+				}
+			}
+		}()
+	})
+}
+
+// route passes typed bytes to the dashboard as keys, or to prompts as lines
+// while one is waiting. Lines typed with no prompt waiting are dropped, so a
+// stray answer can't be taken for the reply to a later question.
+func route(b []byte) {
+	input.mu.Lock()
+	defer input.mu.Unlock()
+
+	if input.keys != nil && len(input.prompts) == 0 {
+		for _, c := range b {
+			input.keys(c)
+		}
+		return
+	}
+
+	input.partial = append(input.partial, b...)
+	for {
+		i := bytes.IndexByte(input.partial, '\n')
+		if i < 0 {
+			return
+		}
+		line := strings.TrimSpace(string(input.partial[:i]))
+		input.partial = input.partial[i+1:]
+		if len(input.prompts) > 0 {
+			input.prompts[0] <- line
+			input.prompts = input.prompts[1:]
+		}
+	}
+}
+
+// setKeys installs the dashboard's key handler, nil to remove it
+func setKeys(fn func(b byte)) {
+	startInput()
+	input.mu.Lock()
+	defer input.mu.Unlock()
+	input.keys = fn
+}
+
+// Prompt shows prompt and returns the next line typed on the terminal after
+// it. A running dashboard steps aside until the answer is in.
+func Prompt(ctx context.Context, prompt string) (string, error) {
+	startInput()
+
+	resume := suspend()
+	defer resume()
+
+	ch := make(chan string, 1)
+	input.mu.Lock()
+	if input.closed {
+		input.mu.Unlock()
+		return "", fmt.Errorf("standard input closed")
+	}
+	// Only input typed after the question counts
+	if len(input.prompts) == 0 {
+		input.partial = nil
+	}
+	input.prompts = append(input.prompts, ch)
+	input.mu.Unlock()
+
+	fmt.Print(prompt)
+	select {
+	case <-ctx.Done():
+		input.mu.Lock()
+		for i, p := range input.prompts {
+			if p == ch {
+				input.prompts = append(input.prompts[:i], input.prompts[i+1:]...)
+				break
+			}
+		}
+		input.mu.Unlock()
+		return "", ctx.Err()
+	case line, ok := <-ch:
+		if !ok {
+			return "", fmt.Errorf("standard input closed")
+		}
+		return line, nil
+	}
+}
+
+// PromptSecret is Prompt without echoing the answer, e.g. for a passphrase
+func PromptSecret(ctx context.Context, prompt string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if IsTerminal(os.Stdin) {
+		if old, err := noEcho(fd); err == nil {
+			defer func() {
+				restore(fd, old)
+				fmt.Println()
+			}()
+		}
+	}
+	return Prompt(ctx, prompt)
+}
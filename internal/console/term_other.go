@@ -0,0 +1,30 @@
+//go:build !linux && !darwin
+
+package console
+
+import "errors"
+
+var errNoRawMode = errors.New("the dashboard needs Linux or macOS for key input")
+
+// termState is a terminal's settings before the dashboard changed them
+type termState struct{}
+
+// makeRaw is not supported here; the dashboard shows without key bindings
+func makeRaw(fd int) (*termState, error) {
+	return nil, errNoRawMode
+}
+
+// noEcho is not supported here; secrets are typed visibly
+func noEcho(fd int) (*termState, error) {
+	return nil, errNoRawMode
+}
+
+// restore is a no-op without raw mode
+func restore(fd int, s *termState) error {
+	return nil
+}
+
+// termSize is unknown here; the dashboard uses a default size
+func termSize(fd int) (int, int, error) {
+	return 0, 0, errNoRawMode
+}
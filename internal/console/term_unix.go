@@ -0,0 +1,57 @@
+//go:build linux || darwin
+
+package console
+
+import "golang.org/x/sys/unix"
+
+// termState is a terminal's settings before the dashboard changed them
+type termState struct {
+	termios unix.Termios
+}
+
+// makeRaw switches the terminal to reading single keys without echo.
+// Ctrl+C still sends an interrupt.
+func makeRaw(fd int) (*termState, error) {
+	t, err := unix.IoctlGetTermios(fd, ioctlReadTermios)
+	if err != nil {
+		return nil, err
+	}
+	old := &termState{termios: *t}
+
+	t.Lflag &^= unix.ICANON | unix.ECHO
+	t.Cc[unix.VMIN] = 1
+	t.Cc[unix.VTIME] = 0
+	if err := unix.IoctlSetTermios(fd, ioctlWriteTermios, t); err != nil {
+		return nil, err
+	}
+	return old, nil
+}
+
+// noEcho stops the terminal from showing what is typed, keeping line input
+func noEcho(fd int) (*termState, error) {
+	t, err := unix.IoctlGetTermios(fd, ioctlReadTermios)
+	if err != nil {
+		return nil, err
+	}
+	old := &termState{termios: *t}
+
+	t.Lflag &^= unix.ECHO
+	if err := unix.IoctlSetTermios(fd, ioctlWriteTermios, t); err != nil {
+		return nil, err
+	}
+	return old, nil
+}
+
+// restore puts the terminal settings back
+func restore(fd int, s *termState) error {
+	return unix.IoctlSetTermios(fd, ioctlWriteTermios, &s.termios)
+}
+
+// termSize returns the terminal's width and height in characters
+func termSize(fd int) (int, int, error) {
+	ws, err := unix.IoctlGetWinsize(fd, unix.TIOCGWINSZ)
+	if err != nil {
+		return 0, 0, err
+	}
+	return int(ws.Col), int(ws.Row), nil
+}
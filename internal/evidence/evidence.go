@@ -0,0 +1,230 @@
+package evidence
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/LLionNg/shopee-livestream-bot/internal/browser"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+)
+
+// Collector saves screenshots, page HTML and recent console/network activity
+// whenever something worth investigating happens. A nil Collector is valid
+// and captures nothing.
+type Collector struct {
+	dir        string
+	maxEntries int
+	logLines   int
+
+	mu   sync.Mutex
+	tabs map[*chromedp.Context]*tabLog
+}
+
+// tabLog is the recent console and network activity of one tab
+type tabLog struct {
+	console []string
+	network []string
+}
+
+// Info describes why evidence was captured
+type Info struct {
+	Reason   string    `json:"reason"`
+	Error    string    `json:"error,omitempty"`
+	URL      string    `json:"url"`
+	StreamID int       `json:"stream_id,omitempty"`
+	Time     time.Time `json:"time"`
+}
+
+// NewCollector creates a collector writing under dir and keeping at most
+// maxEntries captures and logLines recent console/network lines
+func NewCollector(dir string, maxEntries, logLines int) *Collector {
+	return &Collector{
+		dir:        dir,
+		maxEntries: maxEntries,
+		logLines:   logLines,
+		tabs:       map[*chromedp.Context]*tabLog{},
+	}
+}
+
+// Attach starts recording console and network activity of the tab behind
+// ctx. The activity is kept apart from other tabs' and dropped once ctx is
+// done.
+func (c *Collector) Attach(ctx context.Context) {
+	if c == nil {
+		return
+	}
+	tab := chromedp.FromContext(ctx)
+	if tab == nil {
+		return
+	}
+
+	log := &tabLog{}
+	c.mu.Lock()
+	c.tabs[tab] = log
+	c.mu.Unlock()
+	context.AfterFunc(ctx, func() {
+		c.mu.Lock()
+		delete(c.tabs, tab)
+		c.mu.Unlock()
+	})
+
+	chromedp.ListenTarget(ctx, func(ev interface{}) {
+		switch e := ev.(type) {
+		case *runtime.EventConsoleAPICalled:
+			var args []string
+			for _, arg := range e.Args {
+				if arg.Value != nil {
+					args = append(args, string(arg.Value))
+				} else {
+					args = append(args, arg.Description)
+				}
+			}
+			c.record(&log.console, fmt.Sprintf("[%s] %s", e.Type, strings.Join(args, " ")))
+
+		case *runtime.EventExceptionThrown:
+			c.record(&log.console, fmt.Sprintf("[exception] %s", e.ExceptionDetails.Error()))
+
+		case *network.EventRequestWillBeSent:
+			c.record(&log.network, fmt.Sprintf("-> %s %s", e.Request.Method, e.Request.URL))
+
+		case *network.EventResponseReceived:
+			c.record(&log.network, fmt.Sprintf("<- %d %s", e.Response.Status, e.Response.URL))
+
+		case *network.EventLoadingFailed:
+			c.record(&log.network, fmt.Sprintf("xx %s (%s)", e.ErrorText, e.RequestID))
+		}
+	})
+}
+
+func (c *Collector) record(buf *[]string, line string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	*buf = append(*buf, time.Now().Format("15:04:05.000")+" "+line)
+	if len(*buf) > c.logLines {
+		*buf = (*buf)[len(*buf)-c.logLines:]
+	}
+}
+
+// Capture saves a screenshot, the page HTML, the tab's recent logs and
+// info.json under <dir>/<timestamp>[-stream<N>]/ and returns that directory
+func (c *Collector) Capture(ctx context.Context, info Info, cause error) (string, error) {
+	if c == nil {
+		return "", nil
+	}
+
+	info.Time = time.Now()
+	if cause != nil {
+		info.Error = cause.Error()
+	}
+
+	dir, err := c.bundleDir(info)
+	if err != nil {
+		return "", fmt.Errorf("failed to create evidence directory: %w", err)
+	}
+
+	// Collect whatever the page still gives us - a broken page is the point
+	var errs []string
+
+	var html string
+	if err := chromedp.Run(ctx, chromedp.Location(&info.URL), chromedp.OuterHTML("html", &html, chromedp.ByQuery)); err != nil {
+		errs = append(errs, fmt.Sprintf("page: %v", err))
+	} else if err := os.WriteFile(filepath.Join(dir, "page.html"), []byte(html), 0644); err != nil {
+		errs = append(errs, fmt.Sprintf("page.html: %v", err))
+	}
+
+	if err := browser.Screenshot(ctx, filepath.Join(dir, "screenshot.png"), browser.ScreenshotFullPage, ""); err != nil {
+		errs = append(errs, fmt.Sprintf("screenshot: %v", err))
+	}
+
+	var console, netlog string
+	c.mu.Lock()
+	if log := c.tabs[chromedp.FromContext(ctx)]; log != nil {
+		console = strings.Join(log.console, "\n")
+		netlog = strings.Join(log.network, "\n")
+	}
+	c.mu.Unlock()
+
+	if err := os.WriteFile(filepath.Join(dir, "console.log"), []byte(console), 0644); err != nil {
+		errs = append(errs, fmt.Sprintf("console.log: %v", err))
+	}
+	if err := os.WriteFile(filepath.Join(dir, "network.log"), []byte(netlog), 0644); err != nil {
+		errs = append(errs, fmt.Sprintf("network.log: %v", err))
+	}
+
+	data, _ := json.MarshalIndent(info, "", "  ")
+	if err := os.WriteFile(filepath.Join(dir, "info.json"), data, 0644); err != nil {
+		errs = append(errs, fmt.Sprintf("info.json: %v", err))
+	}
+
+	if err := c.prune(); err != nil {
+		errs = append(errs, fmt.Sprintf("retention: %v", err))
+	}
+
+	if len(errs) > 0 {
+		return dir, fmt.Errorf("incomplete evidence: %s", strings.Join(errs, "; "))
+	}
+	return dir, nil
+}
+
+// bundleDir creates a new directory for a capture, named after its time and
+// stream. Captures in the same millisecond get a counter.
+func (c *Collector) bundleDir(info Info) (string, error) {
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return "", err
+	}
+	name := info.Time.Format("20060102-150405.000")
+	if info.StreamID != 0 {
+		name += fmt.Sprintf("-stream%d", info.StreamID)
+	}
+	for n := 1; ; n++ {
+		dir := filepath.Join(c.dir, name)
+		if n > 1 {
+			dir += fmt.Sprintf("-%d", n)
+		}
+		err := os.Mkdir(dir, 0755)
+		if !os.IsExist(err) {
+			return dir, err
+		}
+	}
+}
+
+// prune removes the oldest captures beyond the retention limit
+func (c *Collector) prune() error {
+	if c.maxEntries <= 0 {
+		return nil
+	}
+
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return err
+	}
+
+	var dirs []string
+	for _, e := range entries {
+		if e.IsDir() {
+			dirs = append(dirs, e.Name())
+		}
+	}
+	if len(dirs) <= c.maxEntries {
+		return nil
+	}
+
+	// Names start with a timestamp, so lexical order is chronological
+	sort.Strings(dirs)
+	for _, name := range dirs[:len(dirs)-c.maxEntries] {
+		if err := os.RemoveAll(filepath.Join(c.dir, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
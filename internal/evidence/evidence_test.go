@@ -0,0 +1,47 @@
+package evidence
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBundleDirUnique(t *testing.T) {
+	c := NewCollector(t.TempDir(), 0, 10)
+	now := time.Date(2024, 5, 18, 20, 0, 0, 0, time.Local)
+
+	seen := map[string]bool{}
+	for _, info := range []Info{{StreamID: 1}, {StreamID: 2}, {StreamID: 1}, {}, {}} {
+		info.Time = now
+		dir, err := c.bundleDir(info)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if seen[dir] {
+			t.Fatalf("bundleDir reused %s", dir)
+		}
+		seen[dir] = true
+	}
+	for _, name := range []string{"20240518-200000.000-stream1", "20240518-200000.000-stream1-2", "20240518-200000.000-2"} {
+		if !seen[filepath.Join(c.dir, name)] {
+			t.Errorf("no bundle %s among %v", name, seen)
+		}
+	}
+}
+
+func TestPrune(t *testing.T) {
+	c := NewCollector(t.TempDir(), 2, 10)
+	for _, name := range []string{"20240518-200003.000", "20240518-200001.000-stream2", "20240518-200002.000-stream1"} {
+		if err := os.Mkdir(filepath.Join(c.dir, name), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := c.prune(); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := os.ReadDir(c.dir)
+	if len(entries) != 2 || entries[0].Name() != "20240518-200002.000-stream1" {
+		t.Errorf("kept %v, want the two newest", entries)
+	}
+}
@@ -2,49 +2,98 @@ package livestream
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"sync/atomic"
 	"time"
 
-	"github.com/chromedp/chromedp"
+	"github.com/LLionNg/shopee-livestream-bot/internal/auth"
 	"github.com/LLionNg/shopee-livestream-bot/internal/browser"
+	"github.com/LLionNg/shopee-livestream-bot/internal/cart"
+	"github.com/LLionNg/shopee-livestream-bot/internal/checkout"
 	"github.com/LLionNg/shopee-livestream-bot/internal/config"
+	"github.com/LLionNg/shopee-livestream-bot/internal/console"
+	"github.com/LLionNg/shopee-livestream-bot/internal/evidence"
+	"github.com/LLionNg/shopee-livestream-bot/internal/product"
 	"github.com/LLionNg/shopee-livestream-bot/internal/purchase"
+	"github.com/LLionNg/shopee-livestream-bot/internal/region"
+	"github.com/LLionNg/shopee-livestream-bot/internal/selectors"
+	"github.com/LLionNg/shopee-livestream-bot/internal/shutdown"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
 	"golang.org/x/sync/errgroup"
 )
 
-// Monitor monitors livestreams for product availability
+var (
+	// errPurchaseFailed marks check errors caused by a failed purchase attempt
+	errPurchaseFailed = errors.New("purchase failed")
+	// errBrowserLost means the stream's tab went away with the browser
+	errBrowserLost = errors.New("browser lost")
+	// errLoggedOut means the stream's tab was redirected to the login page
+	errLoggedOut = errors.New("redirected to login")
+)
+
+// Monitor monitors livestreams for product availability. Each stream runs
+// in its own tab of the supervised browser and is reopened after a restart.
 type Monitor struct {
-	ctx      context.Context
-	cfg      *config.Config
-	executor *purchase.Executor
-	streams  []string
+	sup          *browser.Supervisor
+	sessions     *auth.SessionSupervisor
+	cfg          *config.Config
+	dispatcher   *purchase.Dispatcher
+	retry        purchase.RetryPolicy
+	sel          *selectors.Store
+	snapshots    *product.SnapshotStore
+	evidence     *evidence.Collector
+	reservations *cart.Tracker
+	checkout     *checkout.Checkout
+	streams      []string
+	states       map[int]*streamState
+	tasks        *shutdown.Group
 }
 
 // NewMonitor creates a new livestream monitor
-func NewMonitor(ctx context.Context, cfg *config.Config, executor *purchase.Executor) *Monitor {
+func NewMonitor(sup *browser.Supervisor, sessions *auth.SessionSupervisor, cfg *config.Config, sel *selectors.Store, dispatcher *purchase.Dispatcher, ev *evidence.Collector, reservations *cart.Tracker, co *checkout.Checkout) *Monitor {
+	// The states exist from the start so the dashboard controls can reach
+	// a stream before it runs
+	states := make(map[int]*streamState, len(cfg.Shopee.LivestreamURLs))
+	for i, streamURL := range cfg.Shopee.LivestreamURLs {
+		states[i+1] = &streamState{id: i + 1, url: streamURL, buyNow: make(chan struct{}, 1)}
+	}
+
 	return &Monitor{
-		ctx:      ctx,
-		cfg:      cfg,
-		executor: executor,
-		streams:  cfg.Shopee.LivestreamURLs,
+		sup:          sup,
+		sessions:     sessions,
+		cfg:          cfg,
+		dispatcher:   dispatcher,
+		retry:        purchase.NewRetryPolicy(cfg.Purchase),
+		sel:          sel,
+		snapshots:    product.NewSnapshotStore(cfg.Monitoring.SnapshotsDir),
+		evidence:     ev,
+		reservations: reservations,
+		checkout:     co,
+		streams:      cfg.Shopee.LivestreamURLs,
+		states:       states,
 	}
 }
 
-// Start begins monitoring all configured livestreams
-func (m *Monitor) Start(ctx context.Context) error {
-	fmt.Println("Starting livestream monitoring...")
-	fmt.Printf("Monitoring %d livestream(s)\n", len(m.streams))
+// Start begins monitoring all configured livestreams. Streams and the
+// checkouts they start are tracked in tasks so shutdown can wait for them.
+func (m *Monitor) Start(ctx context.Context, tasks *shutdown.Group) error {
+	m.tasks = tasks
+	console.Println("Starting livestream monitoring...")
+	console.Printf("Monitoring %d livestream(s)\n", len(m.streams))
 
 	// Create error group for concurrent monitoring
 	g, ctx := errgroup.WithContext(ctx)
 
 	// Monitor each livestream concurrently
-	for i, streamURL := range m.streams {
-		streamURL := streamURL // capture variable
-		streamID := i + 1
+	for i := range m.streams {
+		st := m.states[i+1]
 
 		g.Go(func() error {
-			return m.monitorStream(ctx, streamURL, streamID)
+			defer tasks.Add(fmt.Sprintf("stream %d", st.id))()
+			return m.monitorStream(ctx, st)
 		})
 	}
 
@@ -56,16 +105,143 @@ func (m *Monitor) Start(ctx context.Context) error {
 	return nil
 }
 
-// monitorStream monitors a single livestream
-func (m *Monitor) monitorStream(ctx context.Context, streamURL string, streamID int) error {
-	fmt.Printf("🎥 [Stream %d] Starting monitor: %s\n", streamID, streamURL)
+// streamState is what a stream remembers across tab reopens
+type streamState struct {
+	id          int
+	url         string
+	lastProduct string
+	lastError   string
+	paused      atomic.Bool   // no automatic purchases, set from the dashboard
+	buyNow      chan struct{} // a purchase asked for from the dashboard
+}
+
+// setState shows what the stream is doing on the dashboard
+func (st *streamState) setState(state string) {
+	console.UpdateStream(st.id, func(s *console.StreamStatus) {
+		s.State = state
+		s.Paused = st.paused.Load()
+	})
+}
+
+// TogglePause stops or restarts automatic purchases on a stream. The stream
+// keeps being watched and can still buy on request.
+func (m *Monitor) TogglePause(streamID int) (bool, error) {
+	st, ok := m.states[streamID]
+	if !ok {
+		return false, fmt.Errorf("no stream %d", streamID)
+	}
+	paused := !st.paused.Load()
+	st.paused.Store(paused)
+	console.UpdateStream(streamID, func(s *console.StreamStatus) { s.Paused = paused })
+	return paused, nil
+}
+
+// BuyNow asks a stream to buy the product it shows at its next chance,
+// whether or not it is paused
+func (m *Monitor) BuyNow(streamID int) error {
+	st, ok := m.states[streamID]
+	if !ok {
+		return fmt.Errorf("no stream %d", streamID)
+	}
+	select {
+	case st.buyNow <- struct{}{}:
+		return nil
+	default:
+		return fmt.Errorf("stream %d already has a purchase requested", streamID)
+	}
+}
+
+// monitorStream monitors a single livestream, reopening its tab whenever the
+// browser is restarted
+func (m *Monitor) monitorStream(ctx context.Context, st *streamState) error {
+	streamID := st.id
+	console.Printf("🎥 [Stream %d] Starting monitor: %s\n", streamID, st.url)
+	st.setState("starting")
+	defer st.setState("stopped")
+
+	for {
+		_, _, changed := m.sup.Current()
+
+		err := m.runTab(ctx, st)
+		if ctx.Err() != nil {
+			console.Printf("🛑 [Stream %d] Stopping monitor\n", streamID)
+			return ctx.Err()
+		}
+
+		if errors.Is(err, errLoggedOut) {
+			console.Printf("🔐 [Stream %d] Redirected to login, waiting for the session to be restored...\n", streamID)
+			st.setState("waiting for login")
+			select {
+			case <-ctx.Done():
+				console.Printf("🛑 [Stream %d] Stopping monitor\n", streamID)
+				return ctx.Err()
+			case <-m.sessions.Ready():
+				console.Printf("🔁 [Stream %d] Session restored, reopening %s\n", streamID, st.url)
+			}
+			continue
+		}
+		if !errors.Is(err, errBrowserLost) {
+			st.setState("error")
+			return err
+		}
+
+		console.Printf("⏳ [Stream %d] Browser lost, waiting for restart...\n", streamID)
+		st.setState("waiting for browser")
+		select {
+		case <-ctx.Done():
+			console.Printf("🛑 [Stream %d] Stopping monitor\n", streamID)
+			return ctx.Err()
+		case <-m.sup.Failed():
+			st.setState("error")
+			return fmt.Errorf("stream %d: %w", streamID, m.sup.Err())
+		case <-changed:
+			console.Printf("🔁 [Stream %d] Reopening %s\n", streamID, st.url)
+		}
+	}
+}
+
+// runTab opens a tab for the stream and checks it until the stream is
+// stopped or the tab is lost
+func (m *Monitor) runTab(ctx context.Context, st *streamState) error {
+	browserCtx, gen, changed := m.sup.Current()
+	if browserCtx.Err() != nil {
+		return errBrowserLost
+	}
+
+	// The tab closes with the stream, interrupting whatever it is running
+	tabCtx, cancel := chromedp.NewContext(browserCtx)
+	defer cancel()
+	stop := context.AfterFunc(ctx, cancel)
+	defer stop()
+
+	crashed := make(chan string, 1)
+	browser.WatchCrash(tabCtx, func(reason string) {
+		crashed <- reason
+	})
+	loggedOut := m.watchLogin(tabCtx)
+	m.evidence.Attach(tabCtx)
+
+	// Collect product data from the stream's API responses
+	watcher := m.watchAPI(tabCtx)
 
 	// Navigate to livestream
-	if err := browser.NavigateWithRetry(m.ctx, streamURL, 3); err != nil {
-		return fmt.Errorf("failed to navigate to stream %d: %w", streamID, err)
+	st.setState("opening")
+	if err := browser.NavigateWithRetry(tabCtx, st.url, 3); err != nil {
+		if tabCtx.Err() != nil {
+			return errBrowserLost
+		}
+		m.captureStreamError(tabCtx, st.id, err)
+		return fmt.Errorf("failed to navigate to stream %d: %w", st.id, err)
 	}
 
-	fmt.Printf("✅ [Stream %d] Successfully loaded livestream\n", streamID)
+	// Remember where short links led so a reopen lands on the same page
+	var resolved string
+	if err := chromedp.Run(tabCtx, chromedp.Location(&resolved)); err == nil && resolved != "" {
+		st.url = resolved
+	}
+
+	console.Printf("✅ [Stream %d] Successfully loaded livestream\n", st.id)
+	st.setState("watching")
 
 	// Start monitoring loop
 	ticker := time.NewTicker(m.cfg.Monitoring.GetCheckInterval())
@@ -74,80 +250,275 @@ func (m *Monitor) monitorStream(ctx context.Context, streamURL string, streamID
 	for {
 		select {
 		case <-ctx.Done():
-			fmt.Printf("🛑 [Stream %d] Stopping monitor\n", streamID)
 			return ctx.Err()
 
+		case <-changed:
+			return errBrowserLost
+
+		case <-tabCtx.Done():
+			return errBrowserLost
+
+		case u := <-loggedOut:
+			m.sessions.ReportLoggedOut(fmt.Sprintf("stream %d redirected to %s", st.id, u))
+			return errLoggedOut
+
+		case reason := <-crashed:
+			console.Printf("💥 [Stream %d] %s\n", st.id, reason)
+			m.sup.ReportCrash(gen, reason)
+			return errBrowserLost
+
+		case <-st.buyNow:
+			if err := m.buyNow(tabCtx, st); err != nil && tabCtx.Err() != nil {
+				return errBrowserLost
+			}
+
 		case <-ticker.C:
 			// Check for product availability
-			if err := m.checkProductAvailability(streamID); err != nil {
-				fmt.Printf("⚠️  [Stream %d] Check error: %v\n", streamID, err)
+			if err := m.checkProductAvailability(tabCtx, st); err != nil {
+				if tabCtx.Err() != nil {
+					return errBrowserLost
+				}
+				console.Printf("⚠️  [Stream %d] Check error: %v\n", st.id, err)
+
+				// The executor keeps its own evidence; capture other errors once
+				if !errors.Is(err, errPurchaseFailed) && err.Error() != st.lastError {
+					m.captureStreamError(tabCtx, st.id, err)
+				}
+				st.lastError = err.Error()
+			} else {
+				st.lastError = ""
 			}
+
+			// Snapshot the pinned product whenever it changes
+			m.trackProduct(tabCtx, st.id, watcher, &st.lastProduct)
+			m.showProduct(tabCtx, st)
 		}
 	}
 }
 
+// captureStreamError saves evidence for a stream error
+func (m *Monitor) captureStreamError(ctx context.Context, streamID int, cause error) {
+	info := evidence.Info{Reason: "stream-error", StreamID: streamID}
+	if dir, err := m.evidence.Capture(ctx, info, cause); err != nil {
+		console.Printf("⚠️  [Stream %d] Evidence capture incomplete (%s): %v\n", streamID, dir, err)
+	}
+}
+
+// watchLogin reports main-frame navigations of the tab to the login page
+func (m *Monitor) watchLogin(ctx context.Context) <-chan string {
+	loginPath := m.cfg.Shopee.GetRegion().LoginPath
+	ch := make(chan string, 1)
+	chromedp.ListenTarget(ctx, func(ev interface{}) {
+		e, ok := ev.(*page.EventFrameNavigated)
+		if !ok || e.Frame.ParentID != "" || !strings.Contains(e.Frame.URL, loginPath) {
+			return
+		}
+		select {
+		case ch <- e.Frame.URL:
+		default:
+		}
+	})
+	return ch
+}
+
 // checkProductAvailability checks if products are available for purchase
-func (m *Monitor) checkProductAvailability(streamID int) error {
-	// Look for "Add to Cart" or "Buy Now" buttons
+func (m *Monitor) checkProductAvailability(ctx context.Context, st *streamState) error {
+	// Look for "Add to Cart" or "Buy Now" buttons using the selector profile
 	// This is a simplified check - real implementation would be more sophisticated
+	selector, err := m.sel.Find(ctx, selectors.AddToCart)
+	if errors.Is(err, selectors.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
 
-	var buttonExists bool
-	
-	// Check for various possible selectors
-	selectors := []string{
-		"button[class*='add-to-cart']",
-		"button[class*='buy-now']",
-		"button[class*='add-cart']",
-		"div[class*='shop-bag'] button",
-		".shopee-button-solid",
-	}
-
-	for _, selector := range selectors {
-		err := chromedp.Run(m.ctx,
-			chromedp.Evaluate(fmt.Sprintf(`!!document.querySelector('%s')`, selector), &buttonExists),
-		)
-		
-		if err == nil && buttonExists {
-			fmt.Printf("[Stream %d] Product available! Attempting purchase...\n", streamID)
-			
-			// Attempt to purchase
-			if err := m.executor.ExecutePurchase(selector); err != nil {
-				fmt.Printf("❌ [Stream %d] Purchase failed: %v\n", streamID, err)
-				return err
-			}
-			
-			fmt.Printf("[Stream %d] Purchase successful!\n", streamID)
-			return nil
+	// Leave the product alone while the session is being restored or the
+	// operator paused the stream
+	if m.dispatcher.Paused() != "" || st.paused.Load() {
+		return nil
+	}
+
+	console.Printf("[Stream %d] Product available! Queueing purchase...\n", st.id)
+	return m.purchase(ctx, st, selector, false)
+}
+
+// buyNow buys the product the stream shows because the operator asked for it
+func (m *Monitor) buyNow(ctx context.Context, st *streamState) error {
+	selector, err := m.sel.Find(ctx, selectors.AddToCart)
+	if err != nil {
+		console.Printf("⚠️  [Stream %d] Nothing to buy right now: %v\n", st.id, err)
+		return err
+	}
+
+	console.Printf("🛒 [Stream %d] Buying on request...\n", st.id)
+	err = m.purchase(ctx, st, selector, true)
+	if err != nil {
+		console.Printf("⚠️  [Stream %d] Check error: %v\n", st.id, err)
+	}
+	return err
+}
+
+// purchase adds the stream's product to the cart through the purchase
+// dispatcher and, with checkout enabled, orders it
+func (m *Monitor) purchase(ctx context.Context, st *streamState, selector string, manual bool) error {
+	streamID := st.id
+	st.setState("buying")
+	defer st.setState("watching")
+
+	// Wait for our turn among the streams that want to buy right now, and
+	// queue again under the retry policy when an attempt fails
+	in := m.purchaseIntent(ctx, streamID, selector)
+	err := m.retry.Do(ctx, func(ctx context.Context, attempt int) error {
+		res := m.dispatcher.Submit(ctx, in)
+		if res.Waited >= time.Second {
+			console.Printf("⏱️  [Stream %d] Waited %s in the purchase queue (priority %d)\n", streamID, res.Waited.Round(100*time.Millisecond), res.Priority)
 		}
+		return res.Err
+	})
+
+	event := console.PurchaseEvent{StreamID: streamID, Name: in.Name, Manual: manual, Err: err}
+	if in.Price.Amount > 0 {
+		event.Price = in.Price.String()
+	}
+	console.RecordPurchase(event)
+
+	if err != nil {
+		console.Printf("❌ [Stream %d] Purchase failed: %v\n", streamID, err)
+		return fmt.Errorf("%w: %v", errPurchaseFailed, err)
 	}
 
+	console.Printf("[Stream %d] Purchase successful!\n", streamID)
+	r := m.trackReservation(ctx, streamID)
+
+	// Only with checkout.enabled; runs in its own tab so the stream goes on.
+	// An order under way is left to finish when the stream stops, and
+	// shutdown waits for it.
+	if m.checkout != nil {
+		checkoutCtx := context.WithoutCancel(ctx)
+		m.tasks.Go(fmt.Sprintf("checkout of %s (stream %d)", r.Name, streamID), func() {
+			_, err := m.checkout.Place(checkoutCtx, r)
+			switch {
+			case errors.Is(err, checkout.ErrAlreadyOrdered):
+				console.Printf("[Stream %d] %v, not checking out again\n", streamID, err)
+			case err != nil:
+				console.Printf("❌ [Stream %d] Checkout failed: %v\n", streamID, err)
+			}
+		})
+	}
 	return nil
 }
 
+// purchaseIntent describes the product on the stream for the purchase
+// dispatcher. A flash sale ending before the queue wait brings the deadline
+// forward.
+func (m *Monitor) purchaseIntent(ctx context.Context, streamID int, selector string) purchase.Intent {
+	now := time.Now()
+	in := purchase.Intent{
+		StreamID: streamID,
+		Selector: selector,
+		Deadline: now.Add(m.cfg.Purchase.GetQueueWait()),
+	}
+
+	if info, err := m.GetProductInfo(ctx); err == nil {
+		in.Name, in.Price = info.Name, info.Price.Current
+	}
+	if card, err := m.sel.Find(ctx, selectors.ProductCard); err == nil {
+		if details, err := m.readCard(ctx, card); err == nil {
+			_, in.ItemID, _ = product.ParseItemURL(details.URL)
+		}
+	}
+	if countdown, err := m.sel.Text(ctx, selectors.FlashSaleCountdown); err == nil {
+		if left, ok := cart.ParseCountdown(countdown); ok && now.Add(left).Before(in.Deadline) {
+			in.Deadline = now.Add(left)
+		}
+	}
+	return in
+}
+
+// trackReservation records how long the item just added stays reserved:
+// the stream's reservation timer when it shows one, otherwise the region's
+// configured reservation time
+func (m *Monitor) trackReservation(ctx context.Context, streamID int) cart.Reservation {
+	now := time.Now()
+	r := cart.Reservation{
+		StreamID: streamID,
+		AddedAt:  now,
+		Until:    now.Add(m.cfg.Cart.ReservationFor(m.cfg.Shopee.Region)),
+		Source:   cart.DeadlineConfig,
+	}
+
+	if timer, err := m.sel.Text(ctx, selectors.ReservationTimer); err == nil {
+		if left, ok := cart.ParseCountdown(timer); ok {
+			r.Until, r.Source = now.Add(left), cart.DeadlinePage
+		}
+	}
+	if name, err := m.sel.Text(ctx, selectors.ProductName); err == nil {
+		r.Name = strings.TrimSpace(name)
+	}
+	if card, err := m.sel.Find(ctx, selectors.ProductCard); err == nil {
+		if details, err := m.readCard(ctx, card); err == nil {
+			r.ShopID, r.ItemID, _ = product.ParseItemURL(details.URL)
+		}
+	}
+	if r.Name == "" && r.ItemID == 0 {
+		r.Name = fmt.Sprintf("item from stream %d", streamID)
+	}
+
+	m.reservations.Track(r)
+	return r
+}
+
+// trackProduct captures a snapshot when the pinned product differs from last
+func (m *Monitor) trackProduct(ctx context.Context, streamID int, w *apiWatcher, last *string) {
+	name, err := m.sel.Text(ctx, selectors.ProductName)
+	if err != nil || strings.TrimSpace(name) == *last {
+		return
+	}
+
+	snap, err := m.CaptureSnapshot(ctx, streamID, w)
+	if err != nil {
+		console.Printf("⚠️  [Stream %d] Snapshot error: %v\n", streamID, err)
+		return
+	}
+	*last = strings.TrimSpace(name)
+
+	console.Printf("📸 [Stream %d] Pinned product: %s - %s (stock: %s)\n", streamID, snap.Title, snap.Price, snap.Stock)
+}
+
+// showProduct puts the stream's current product and flash sale countdown on
+// the dashboard
+func (m *Monitor) showProduct(ctx context.Context, st *streamState) {
+	info, _ := m.GetProductInfo(ctx)
+	countdown, _ := m.sel.Text(ctx, selectors.FlashSaleCountdown)
+
+	console.UpdateStream(st.id, func(s *console.StreamStatus) {
+		s.Product, s.Price, s.Stock = "", "", ""
+		if info != nil {
+			s.Product = info.Name
+			if info.Price.Current.Amount > 0 {
+				s.Price = info.Price.String()
+			}
+			if info.Name != "" {
+				s.Stock = info.Stock.String()
+			}
+		}
+		s.Countdown = strings.TrimSpace(countdown)
+	})
+}
+
 // CheckFlashSale checks for flash sale countdown
-func (m *Monitor) CheckFlashSale(streamID int) (*FlashSale, error) {
+func (m *Monitor) CheckFlashSale(ctx context.Context, streamID int) (*FlashSale, error) {
 	// Look for flash sale timer/countdown
-	var hasTimer bool
-	
-	err := chromedp.Run(m.ctx,
-		chromedp.Evaluate(`!!document.querySelector('[class*="countdown"]')`, &hasTimer),
-	)
-	
-	if err != nil || !hasTimer {
+	countdownText, err := m.sel.Text(ctx, selectors.FlashSaleCountdown)
+	if errors.Is(err, selectors.ErrNotFound) {
 		return nil, nil
 	}
-
-	// Extract countdown time
-	var countdownText string
-	err = chromedp.Run(m.ctx,
-		chromedp.Text(`[class*="countdown"]`, &countdownText, chromedp.ByQuery),
-	)
-	
 	if err != nil {
 		return nil, err
 	}
 
-	fmt.Printf("[Stream %d] Flash sale detected: %s\n", streamID, countdownText)
+	console.Printf("[Stream %d] Flash sale detected: %s\n", streamID, countdownText)
 
 	return &FlashSale{
 		StreamID:  streamID,
@@ -157,34 +528,33 @@ func (m *Monitor) CheckFlashSale(streamID int) (*FlashSale, error) {
 }
 
 // GetProductInfo extracts product information from livestream
-func (m *Monitor) GetProductInfo() (*ProductInfo, error) {
+func (m *Monitor) GetProductInfo(ctx context.Context) (*ProductInfo, error) {
 	var info ProductInfo
+	r := m.cfg.Shopee.GetRegion()
 
 	// Extract product name
-	var name string
-	err := chromedp.Run(m.ctx,
-		chromedp.Text(`[class*="product-name"], [class*="product-title"]`, &name, chromedp.ByQuery),
-	)
-	if err == nil {
-		info.Name = name
+	if name, err := m.sel.Text(ctx, selectors.ProductName); err == nil {
+		info.Name = strings.TrimSpace(name)
 	}
 
-	// Extract price
-	var price string
-	err = chromedp.Run(m.ctx,
-		chromedp.Text(`[class*="price"], [class*="amount"]`, &price, chromedp.ByQuery),
-	)
+	// Extract price - original price and discount badge are optional
+	price, err := m.sel.Text(ctx, selectors.ProductPrice)
 	if err == nil {
-		info.Price = price
+		original, _ := m.sel.Text(ctx, selectors.ProductOrigPrice)
+		discount, _ := m.sel.Text(ctx, selectors.ProductDiscount)
+
+		info.Price, err = product.ParsePrice(price, original, discount, r.Currency)
+		if err != nil {
+			return &info, fmt.Errorf("failed to parse price: %w", err)
+		}
 	}
 
 	// Extract stock info
-	var stock string
-	err = chromedp.Run(m.ctx,
-		chromedp.Text(`[class*="stock"], [class*="quantity"]`, &stock, chromedp.ByQuery),
-	)
-	if err == nil {
-		info.Stock = stock
+	if stock, err := m.sel.Text(ctx, selectors.ProductStock); err == nil {
+		info.Stock, err = product.ParseStock(stock, r.Texts[region.TextSoldOut])
+		if err != nil {
+			return &info, fmt.Errorf("failed to parse stock: %w", err)
+		}
 	}
 
 	return &info, nil
@@ -193,8 +563,8 @@ func (m *Monitor) GetProductInfo() (*ProductInfo, error) {
 // ProductInfo holds product information
 type ProductInfo struct {
 	Name  string
-	Price string
-	Stock string
+	Price product.Price
+	Stock product.Stock
 }
 
 // FlashSale represents a flash sale event
@@ -202,4 +572,4 @@ type FlashSale struct {
 	StreamID  int
 	Countdown string
 	Detected  time.Time
-}
\ No newline at end of file
+}
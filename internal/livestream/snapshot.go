@@ -0,0 +1,216 @@
+package livestream
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/LLionNg/shopee-livestream-bot/internal/console"
+	"github.com/LLionNg/shopee-livestream-bot/internal/product"
+	"github.com/LLionNg/shopee-livestream-bot/internal/selectors"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+// maxAPIItems caps how many items an apiWatcher remembers; a stream loads
+// recommendations all the time, and only recent items can still be pinned
+const maxAPIItems = 200
+
+// apiWatcher collects item data from the API responses a stream page loads
+type apiWatcher struct {
+	mu    sync.Mutex
+	items map[int64]*product.Snapshot
+	order []int64 // item IDs, oldest first
+}
+
+// watchAPI starts intercepting JSON API responses on the tab behind ctx
+func (m *Monitor) watchAPI(ctx context.Context) *apiWatcher {
+	w := newAPIWatcher()
+	r := m.cfg.Shopee.GetRegion()
+
+	chromedp.ListenTarget(ctx, func(ev interface{}) {
+		e, ok := ev.(*network.EventResponseReceived)
+		if !ok || !strings.Contains(e.Response.URL, "/api/") || !strings.Contains(e.Response.MimeType, "json") {
+			return
+		}
+
+		// Fetching the body must not block the event loop
+		go func(id network.RequestID) {
+			var body []byte
+			err := chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+				var err error
+				body, err = network.GetResponseBody(id).Do(ctx)
+				return err
+			}))
+			if err != nil {
+				return
+			}
+
+			for _, snap := range product.ParseAPIItems(body, r.Currency, r.ImageBaseURL()) {
+				w.add(snap)
+			}
+		}(e.RequestID)
+	})
+
+	return w
+}
+
+func newAPIWatcher() *apiWatcher {
+	return &apiWatcher{items: make(map[int64]*product.Snapshot)}
+}
+
+// add remembers an item, forgetting the oldest once maxAPIItems are held
+func (w *apiWatcher) add(snap *product.Snapshot) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, ok := w.items[snap.ItemID]; !ok {
+		w.order = append(w.order, snap.ItemID)
+	}
+	w.items[snap.ItemID] = snap
+	for len(w.order) > maxAPIItems {
+		delete(w.items, w.order[0])
+		w.order = w.order[1:]
+	}
+}
+
+// lookup returns API data for the item shown in the DOM: by ID when the
+// card links it, otherwise by title. Nil when nothing matches, so data for
+// another product is never merged in.
+func (w *apiWatcher) lookup(itemID int64, title string) *product.Snapshot {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if itemID != 0 {
+		return w.items[itemID]
+	}
+	if title == "" {
+		return nil
+	}
+	// Newest first, the pinned product was most likely loaded last
+	for i := len(w.order) - 1; i >= 0; i-- {
+		if snap := w.items[w.order[i]]; sameTitle(title, snap.Title) {
+			return snap
+		}
+	}
+	return nil
+}
+
+// sameTitle reports whether a DOM title names the API item, allowing for
+// the DOM cutting long titles off with an ellipsis
+func sameTitle(dom, api string) bool {
+	dom, api = strings.TrimSpace(dom), strings.TrimSpace(api)
+	if dom == "" || api == "" {
+		return false
+	}
+	if strings.EqualFold(dom, api) {
+		return true
+	}
+	cut := strings.TrimRight(strings.TrimSuffix(dom, "…"), ".")
+	return cut != dom && cut != "" && len(api) >= len(cut) && strings.EqualFold(api[:len(cut)], cut)
+}
+
+// cardDetails is what the DOM exposes about the pinned product card
+type cardDetails struct {
+	URL    string `json:"url"`
+	Image  string `json:"image"`
+	Sold   string `json:"sold"`
+	Rating string `json:"rating"`
+}
+
+// CaptureSnapshot records the pinned product from the DOM, merged with any
+// intercepted API data, along with a screenshot of the product card
+func (m *Monitor) CaptureSnapshot(ctx context.Context, streamID int, w *apiWatcher) (*product.Snapshot, error) {
+	info, err := m.GetProductInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	snap := &product.Snapshot{
+		Title:      info.Name,
+		Price:      info.Price,
+		Stock:      info.Stock,
+		Source:     product.SourceDOM,
+		StreamID:   streamID,
+		CapturedAt: time.Now(),
+	}
+
+	cardSelector, cardErr := m.sel.Find(ctx, selectors.ProductCard)
+	if cardErr == nil {
+		details, err := m.readCard(ctx, cardSelector)
+		if err != nil {
+			return nil, err
+		}
+
+		snap.URL = details.URL
+		snap.ImageURL = details.Image
+		if shopID, itemID, ok := product.ParseItemURL(details.URL); ok {
+			snap.ShopID, snap.ItemID = shopID, itemID
+		}
+		if sold, err := product.ParseSold(details.Sold); err == nil {
+			snap.Sold = sold
+		}
+		if rating, err := product.ParseRating(details.Rating); err == nil {
+			snap.Rating = rating
+		}
+	}
+
+	if w != nil {
+		if api := w.lookup(snap.ItemID, snap.Title); api != nil {
+			snap.Merge(api)
+			if snap.Title == api.Title {
+				snap.Source = product.SourceAPI
+			}
+		}
+	}
+
+	if snap.ItemID == 0 && snap.Title == "" {
+		return nil, fmt.Errorf("no pinned product found")
+	}
+
+	var screenshot []byte
+	if cardErr == nil {
+		if err := chromedp.Run(ctx, chromedp.Screenshot(cardSelector, &screenshot, chromedp.ByQuery)); err != nil {
+			console.Printf("⚠️  [Stream %d] Failed to capture product card: %v\n", streamID, err)
+		}
+	}
+
+	if _, err := m.snapshots.Save(snap, screenshot); err != nil {
+		return snap, err
+	}
+	return snap, nil
+}
+
+// readCard reads link, image, sold count and rating from the product card
+func (m *Monitor) readCard(ctx context.Context, cardSelector string) (*cardDetails, error) {
+	card, _ := json.Marshal(cardSelector)
+	sold, _ := json.Marshal(m.sel.Get(selectors.ProductSold))
+	rating, _ := json.Marshal(m.sel.Get(selectors.ProductRating))
+
+	script := fmt.Sprintf(`(() => {
+		const card = document.querySelector(%s);
+		if (!card) return null;
+		const first = list => {
+			for (const s of list) {
+				const el = card.querySelector(s) || document.querySelector(s);
+				if (el) return (el.innerText || '').trim();
+			}
+			return '';
+		};
+		const link = card.closest('a[href]') || card.querySelector('a[href*="-i."], a[href*="/product/"], a[href]');
+		const img = card.querySelector('img');
+		return {
+			url: link ? link.href : '',
+			image: img ? (img.currentSrc || img.src) : '',
+			sold: first(%s),
+			rating: first(%s),
+		};
+	})()`, card, sold, rating)
+
+	var details cardDetails
+	if err := chromedp.Run(ctx, chromedp.Evaluate(script, &details)); err != nil {
+		return nil, fmt.Errorf("failed to read product card: %w", err)
+	}
+	return &details, nil
+}
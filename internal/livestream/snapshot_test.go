@@ -0,0 +1,56 @@
+package livestream
+
+import (
+	"testing"
+
+	"github.com/LLionNg/shopee-livestream-bot/internal/product"
+)
+
+func TestAPIWatcherLookup(t *testing.T) {
+	w := newAPIWatcher()
+	w.add(&product.Snapshot{ItemID: 1, Title: "Ceramic Mug 350ml"})
+	w.add(&product.Snapshot{ItemID: 2, Title: "Desk Lamp with USB Charging Port"})
+	w.add(&product.Snapshot{ItemID: 3, Title: "Recommended Socks"})
+
+	tests := []struct {
+		name   string
+		itemID int64
+		title  string
+		want   int64 // 0 for no match
+	}{
+		{"by id", 2, "", 2},
+		{"unknown id", 9, "Ceramic Mug 350ml", 0},
+		{"by title", 0, "ceramic mug 350ml", 1},
+		{"cut title", 0, "Desk Lamp with USB…", 2},
+		{"cut with dots", 0, "Desk Lamp with...", 2},
+		{"other title", 0, "Cotton T-Shirt", 0},
+		{"no id or title", 0, "", 0},
+	}
+	for _, tt := range tests {
+		got := w.lookup(tt.itemID, tt.title)
+		switch {
+		case tt.want == 0 && got != nil:
+			t.Errorf("%s: got item %d, want none", tt.name, got.ItemID)
+		case tt.want != 0 && (got == nil || got.ItemID != tt.want):
+			t.Errorf("%s: got %+v, want item %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAPIWatcherCap(t *testing.T) {
+	w := newAPIWatcher()
+	for id := int64(1); id <= maxAPIItems+10; id++ {
+		w.add(&product.Snapshot{ItemID: id})
+	}
+	w.add(&product.Snapshot{ItemID: maxAPIItems + 10}) // seen again
+
+	if len(w.items) != maxAPIItems || len(w.order) != maxAPIItems {
+		t.Fatalf("holding %d items (%d ordered), want %d", len(w.items), len(w.order), maxAPIItems)
+	}
+	if w.lookup(10, "") != nil {
+		t.Error("oldest items were kept")
+	}
+	if w.lookup(11, "") == nil || w.lookup(maxAPIItems+10, "") == nil {
+		t.Error("recent items were dropped")
+	}
+}
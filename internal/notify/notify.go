@@ -0,0 +1,117 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/LLionNg/shopee-livestream-bot/internal/config"
+	"github.com/LLionNg/shopee-livestream-bot/internal/console"
+)
+
+// Level is how urgent a notification is
+type Level string
+
+const (
+	Info     Level = "info"
+	Warning  Level = "warning"
+	Critical Level = "critical"
+)
+
+// icons prefix messages so levels stand out in chat apps
+var icons = map[Level]string{
+	Info:     "ℹ️",
+	Warning:  "⚠️",
+	Critical: "🚨",
+}
+
+// Event is one notification
+type Event struct {
+	Level   Level     `json:"level"`
+	Title   string    `json:"title"`
+	Message string    `json:"message"`
+	Time    time.Time `json:"time"`
+}
+
+// Notifier posts events to a webhook. The payload carries "content" and
+// "text" so Discord and Slack incoming webhooks both display it. A nil
+// Notifier only prints to the console.
+type Notifier struct {
+	url    string
+	client *http.Client
+	sends  sync.WaitGroup
+}
+
+// New returns a notifier for the configured webhook, or nil when
+// notifications are disabled
+func New(cfg config.NotificationConfig) *Notifier {
+	if !cfg.Enabled || cfg.WebhookURL == "" {
+		return nil
+	}
+	return &Notifier{
+		url:    cfg.WebhookURL,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Send delivers an event. Delivery failures are returned but the event is
+// always printed locally.
+func (n *Notifier) Send(ctx context.Context, level Level, title, message string) error {
+	ev := Event{Level: level, Title: title, Message: message, Time: time.Now()}
+	text := fmt.Sprintf("%s %s: %s", icons[level], title, message)
+	console.Println(text)
+
+	if n == nil {
+		return nil
+	}
+	n.sends.Add(1)
+	defer n.sends.Done()
+
+	body, err := json.Marshal(struct {
+		Event
+		Content string `json:"content"`
+		Text    string `json:"text"`
+	}{ev, text, text})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create notification request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("notification webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// Flush waits for notifications still being delivered, or until ctx is done
+func (n *Notifier) Flush(ctx context.Context) error {
+	if n == nil {
+		return nil
+	}
+	done := make(chan struct{})
+	go func() {
+		n.sends.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("notifications still sending: %w", ctx.Err())
+	}
+}
@@ -0,0 +1,131 @@
+package product
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/LLionNg/shopee-livestream-bot/internal/region"
+)
+
+var (
+	rangeSeparator = regexp.MustCompile(`\s*[-–~]\s*`)
+	percentPattern = regexp.MustCompile(`(\d{1,3})\s*%`)
+	numberPattern  = regexp.MustCompile(`\d[\d,.\s]*`)
+)
+
+// ParseMoney parses a single displayed price such as "฿1,234.50"
+func ParseMoney(text string, c region.Currency) (Money, error) {
+	amount, err := c.Parse(text)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: amount, Currency: c}, nil
+}
+
+// ParsePriceRange parses a price that may be a variant range such as
+// "฿99 - ฿149". A single price returns the same value for min and max.
+func ParsePriceRange(text string, c region.Currency) (Money, Money, error) {
+	parts := rangeSeparator.Split(strings.TrimSpace(text), -1)
+
+	var amounts []Money
+	for _, part := range parts {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		m, err := ParseMoney(part, c)
+		if err != nil {
+			return Money{}, Money{}, err
+		}
+		amounts = append(amounts, m)
+	}
+
+	switch len(amounts) {
+	case 1:
+		return amounts[0], amounts[0], nil
+	case 2:
+		if amounts[1].Amount < amounts[0].Amount {
+			amounts[0], amounts[1] = amounts[1], amounts[0]
+		}
+		return amounts[0], amounts[1], nil
+	default:
+		return Money{}, Money{}, fmt.Errorf("invalid price %q", text)
+	}
+}
+
+// ParseDiscount parses a discount badge such as "-25%", "25% OFF" or "ลด 25%"
+func ParseDiscount(text string) (int, error) {
+	match := percentPattern.FindStringSubmatch(text)
+	if match == nil {
+		return 0, fmt.Errorf("no discount in %q", text)
+	}
+
+	percent, err := strconv.Atoi(match[1])
+	if err != nil || percent > 100 {
+		return 0, fmt.Errorf("invalid discount %q", text)
+	}
+	return percent, nil
+}
+
+// ParsePrice builds a Price from the current price text and the optional
+// original price and discount badge texts. The discount is derived from the
+// two prices when the badge is missing.
+func ParsePrice(current, original, discount string, c region.Currency) (Price, error) {
+	var p Price
+
+	low, high, err := ParsePriceRange(current, c)
+	if err != nil {
+		return Price{}, err
+	}
+	p.Current, p.Max = low, high
+
+	if strings.TrimSpace(original) != "" {
+		orig, _, err := ParsePriceRange(original, c)
+		if err != nil {
+			return Price{}, fmt.Errorf("original price: %w", err)
+		}
+		p.Original = orig
+	}
+
+	if strings.TrimSpace(discount) != "" {
+		if percent, err := ParseDiscount(discount); err == nil {
+			p.DiscountPercent = percent
+		}
+	}
+	if p.DiscountPercent == 0 && p.HasDiscount() {
+		p.DiscountPercent = int((p.Original.Amount - p.Current.Amount) * 100 / p.Original.Amount)
+	}
+
+	return p, nil
+}
+
+// ParseStock parses a stock label such as "1,234 pieces available",
+// "เหลือ 5 ชิ้น" or a sold-out caption listed in soldOut
+func ParseStock(text string, soldOut []string) (Stock, error) {
+	lower := strings.ToLower(strings.TrimSpace(text))
+	for _, caption := range soldOut {
+		if strings.Contains(lower, strings.ToLower(caption)) {
+			return Stock{SoldOut: true, Known: true}, nil
+		}
+	}
+
+	match := numberPattern.FindString(lower)
+	if match == "" {
+		return Stock{}, fmt.Errorf("no quantity in %q", text)
+	}
+
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, match)
+
+	quantity, err := strconv.Atoi(digits)
+	if err != nil {
+		return Stock{}, fmt.Errorf("invalid quantity %q", text)
+	}
+
+	return Stock{Quantity: quantity, SoldOut: quantity == 0, Known: true}, nil
+}
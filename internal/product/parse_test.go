@@ -0,0 +1,232 @@
+package product
+
+import (
+	"testing"
+
+	"github.com/LLionNg/shopee-livestream-bot/internal/region"
+)
+
+func currency(t *testing.T, code string) region.Currency {
+	t.Helper()
+	r, err := region.Lookup(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r.Currency
+}
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		region string
+		text   string
+		want   int64
+	}{
+		{"th", "฿1,234", 123400},
+		{"th", "฿1,234.50", 123450},
+		{"th", " ฿ 99 ", 9900},
+		{"vn", "₫125.000", 125000},
+		{"vn", "125.000₫", 125000},
+		{"my", "RM12.90", 1290},
+		{"ph", "₱1,599", 159900},
+		{"sg", "$8.5", 850},
+		{"id", "Rp1.250.000", 1250000},
+		{"tw", "$1,299", 1299},
+		{"br", "R$1.234,56", 123456},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.region+"/"+tt.text, func(t *testing.T) {
+			got, err := ParseMoney(tt.text, currency(t, tt.region))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.Amount != tt.want {
+				t.Errorf("got %d, want %d", got.Amount, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMoneyInvalid(t *testing.T) {
+	tests := []struct {
+		region string
+		text   string
+	}{
+		{"th", ""},
+		{"th", "free"},
+		{"th", "฿1.234"},
+		{"vn", "₫125,5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.region+"/"+tt.text, func(t *testing.T) {
+			if _, err := ParseMoney(tt.text, currency(t, tt.region)); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestParsePriceRange(t *testing.T) {
+	tests := []struct {
+		text     string
+		min, max int64
+	}{
+		{"฿99", 9900, 9900},
+		{"฿99 - ฿149", 9900, 14900},
+		{"฿99-฿149", 9900, 14900},
+		{"฿1,000 – ฿2,500", 100000, 250000},
+		{"฿149 ~ ฿99", 9900, 14900},
+	}
+
+	th := currency(t, "th")
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			low, high, err := ParsePriceRange(tt.text, th)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if low.Amount != tt.min || high.Amount != tt.max {
+				t.Errorf("got %d-%d, want %d-%d", low.Amount, high.Amount, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestParseDiscount(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    int
+		wantErr bool
+	}{
+		{"-25%", 25, false},
+		{"25% OFF", 25, false},
+		{"ลด 50%", 50, false},
+		{"100%", 100, false},
+		{"hot", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got, err := ParseDiscount(tt.text)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePrice(t *testing.T) {
+	tests := []struct {
+		name     string
+		current  string
+		original string
+		discount string
+		want     Price
+	}{
+		{
+			name:    "plain",
+			current: "฿199",
+			want:    Price{Current: Money{Amount: 19900}, Max: Money{Amount: 19900}},
+		},
+		{
+			name:     "discount badge",
+			current:  "฿150",
+			original: "฿200",
+			discount: "-25%",
+			want: Price{
+				Current:         Money{Amount: 15000},
+				Max:             Money{Amount: 15000},
+				Original:        Money{Amount: 20000},
+				DiscountPercent: 25,
+			},
+		},
+		{
+			name:     "derived discount",
+			current:  "฿99",
+			original: "฿198",
+			want: Price{
+				Current:         Money{Amount: 9900},
+				Max:             Money{Amount: 9900},
+				Original:        Money{Amount: 19800},
+				DiscountPercent: 50,
+			},
+		},
+		{
+			name:    "variant range",
+			current: "฿99 - ฿149",
+			want:    Price{Current: Money{Amount: 9900}, Max: Money{Amount: 14900}},
+		},
+	}
+
+	th := currency(t, "th")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePrice(tt.current, tt.original, tt.discount, th)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.Current.Amount != tt.want.Current.Amount ||
+				got.Max.Amount != tt.want.Max.Amount ||
+				got.Original.Amount != tt.want.Original.Amount ||
+				got.DiscountPercent != tt.want.DiscountPercent {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseStock(t *testing.T) {
+	soldOut := []string{"สินค้าหมด", "Sold Out"}
+
+	tests := []struct {
+		text    string
+		want    Stock
+		wantErr bool
+	}{
+		{"1,234 pieces available", Stock{Quantity: 1234, Known: true}, false},
+		{"เหลือ 5 ชิ้น", Stock{Quantity: 5, Known: true}, false},
+		{"มีสินค้าทั้งหมด 120 ชิ้น", Stock{Quantity: 120, Known: true}, false},
+		{"0 pieces available", Stock{SoldOut: true, Known: true}, false},
+		{"SOLD OUT", Stock{SoldOut: true, Known: true}, false},
+		{"สินค้าหมด", Stock{SoldOut: true, Known: true}, false},
+		{"in stock", Stock{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got, err := ParseStock(tt.text, soldOut)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPriceString(t *testing.T) {
+	th := currency(t, "th")
+	tests := []struct {
+		price Price
+		want  string
+	}{
+		{Price{Current: Money{19900, th}, Max: Money{19900, th}}, "฿199"},
+		{Price{Current: Money{123450, th}, Max: Money{123450, th}}, "฿1,234.50"},
+		{Price{Current: Money{9900, th}, Max: Money{14900, th}}, "฿99 - ฿149"},
+		{
+			Price{Current: Money{9900, th}, Max: Money{9900, th}, Original: Money{19800, th}, DiscountPercent: 50},
+			"฿99 (was ฿198, -50%)",
+		},
+	}
+
+	for _, tt := range tests {
+		if got := tt.price.String(); got != tt.want {
+			t.Errorf("got %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package product
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/LLionNg/shopee-livestream-bot/internal/region"
+)
+
+// Money is an amount in minor units (e.g. satang) of a currency
+type Money struct {
+	Amount   int64
+	Currency region.Currency
+}
+
+// IsZero reports whether no amount was recorded
+func (m Money) IsZero() bool {
+	return m.Amount == 0 && m.Currency.Code == ""
+}
+
+// String formats the amount the way the site displays it
+func (m Money) String() string {
+	if m.IsZero() {
+		return "-"
+	}
+	return m.Currency.Format(m.Amount)
+}
+
+type moneyJSON struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// MarshalJSON stores the amount with its ISO currency code
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJSON{Amount: m.Amount, Currency: m.Currency.Code})
+}
+
+// UnmarshalJSON restores the amount and looks up the currency rules
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var v moneyJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	m.Amount = v.Amount
+	m.Currency = region.Currency{Code: v.Currency}
+	if c, ok := region.CurrencyByCode(v.Currency); ok {
+		m.Currency = c
+	}
+	return nil
+}
+
+// Price holds the selling price of a product, its pre-discount price and,
+// for multi-variant items, the upper end of the price range
+type Price struct {
+	Current         Money `json:"current"`
+	Max             Money `json:"max"`
+	Original        Money `json:"original"`
+	DiscountPercent int   `json:"discount_percent"`
+}
+
+// IsRange reports whether the price spans several variants
+func (p Price) IsRange() bool {
+	return p.Max.Amount > p.Current.Amount
+}
+
+// HasDiscount reports whether the product is sold below its original price
+func (p Price) HasDiscount() bool {
+	return p.Original.Amount > p.Current.Amount
+}
+
+// String formats the price, e.g. "฿99 - ฿149 (was ฿199, -50%)"
+func (p Price) String() string {
+	s := p.Current.String()
+	if p.IsRange() {
+		s += " - " + p.Max.String()
+	}
+	if p.HasDiscount() {
+		s += fmt.Sprintf(" (was %s, -%d%%)", p.Original, p.DiscountPercent)
+	}
+	return s
+}
+
+// Stock is the available quantity of a product
+type Stock struct {
+	Quantity int  `json:"quantity"`
+	SoldOut  bool `json:"sold_out"`
+	Known    bool `json:"known"`
+}
+
+// Available reports whether the product can still be bought.
+// Unknown stock is treated as available.
+func (s Stock) Available() bool {
+	return !s.SoldOut && (!s.Known || s.Quantity > 0)
+}
+
+// String formats the stock for display
+func (s Stock) String() string {
+	switch {
+	case s.SoldOut:
+		return "sold out"
+	case !s.Known:
+		return "unknown"
+	default:
+		return fmt.Sprintf("%d", s.Quantity)
+	}
+}
@@ -0,0 +1,310 @@
+package product
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/LLionNg/shopee-livestream-bot/internal/region"
+)
+
+// Snapshot sources
+const (
+	SourceDOM = "dom"
+	SourceAPI = "api"
+)
+
+// apiPriceScale is the factor Shopee's API multiplies prices by
+const apiPriceScale = 100000
+
+var itemURLPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`-i\.(\d+)\.(\d+)`),     // /Some-Product-i.<shop>.<item>
+	regexp.MustCompile(`/product/(\d+)/(\d+)`), // /product/<shop>/<item>
+}
+
+var (
+	unsafeChars   = regexp.MustCompile(`[^\p{L}\p{N}._-]+`)
+	soldPattern   = regexp.MustCompile(`(\d+(?:[.,]\d+)*)\s*(mil|k|m|พัน|ล้าน|rb|jt)?`)
+	ratingPattern = regexp.MustCompile(`\d+(?:[.,]\d+)?`)
+)
+
+// Snapshot is everything known about a pinned product at one point in time
+type Snapshot struct {
+	ItemID     int64     `json:"item_id"`
+	ShopID     int64     `json:"shop_id"`
+	ModelIDs   []int64   `json:"model_ids,omitempty"`
+	URL        string    `json:"url"`
+	ImageURL   string    `json:"image_url"`
+	Title      string    `json:"title"`
+	Price      Price     `json:"price"`
+	Stock      Stock     `json:"stock"`
+	Sold       int       `json:"sold"`
+	Rating     float64   `json:"rating"`
+	Source     string    `json:"source"`
+	StreamID   int       `json:"stream_id"`
+	CapturedAt time.Time `json:"captured_at"`
+	Screenshot string    `json:"screenshot,omitempty"`
+}
+
+// Key identifies the product a snapshot belongs to
+func (s *Snapshot) Key() string {
+	if s.ItemID != 0 {
+		return fmt.Sprintf("%d.%d", s.ShopID, s.ItemID)
+	}
+	return s.Title
+}
+
+// Merge fills fields missing from s with values from other
+func (s *Snapshot) Merge(other *Snapshot) {
+	if s.ItemID == 0 {
+		s.ItemID, s.ShopID = other.ItemID, other.ShopID
+	}
+	if len(s.ModelIDs) == 0 {
+		s.ModelIDs = other.ModelIDs
+	}
+	if s.URL == "" {
+		s.URL = other.URL
+	}
+	if s.ImageURL == "" {
+		s.ImageURL = other.ImageURL
+	}
+	if s.Title == "" {
+		s.Title = other.Title
+	}
+	if s.Price.Current.IsZero() {
+		s.Price = other.Price
+	}
+	if !s.Stock.Known {
+		s.Stock = other.Stock
+	}
+	if s.Sold == 0 {
+		s.Sold = other.Sold
+	}
+	if s.Rating == 0 {
+		s.Rating = other.Rating
+	}
+}
+
+// ParseItemURL extracts the shop and item IDs from a product URL
+func ParseItemURL(url string) (shopID, itemID int64, ok bool) {
+	for _, pattern := range itemURLPatterns {
+		match := pattern.FindStringSubmatch(url)
+		if match == nil {
+			continue
+		}
+		shopID, err1 := strconv.ParseInt(match[1], 10, 64)
+		itemID, err2 := strconv.ParseInt(match[2], 10, 64)
+		if err1 == nil && err2 == nil {
+			return shopID, itemID, true
+		}
+	}
+	return 0, 0, false
+}
+
+// ParseSold parses a sold counter such as "1,234 sold", "1.2k sold" or
+// "ขายแล้ว 3.4พัน". Without a k/m suffix separators are thousands separators.
+func ParseSold(text string) (int, error) {
+	match := soldPattern.FindStringSubmatch(strings.ToLower(text))
+	if match == nil {
+		return 0, fmt.Errorf("no sold count in %q", text)
+	}
+
+	multiplier := 1.0
+	switch match[2] {
+	case "k", "พัน", "rb", "mil":
+		multiplier = 1000
+	case "m", "ล้าน", "jt":
+		multiplier = 1000000
+	}
+
+	number := strings.ReplaceAll(match[1], ",", ".")
+	if multiplier == 1 {
+		number = strings.ReplaceAll(number, ".", "")
+	}
+
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid sold count %q", text)
+	}
+	return int(value * multiplier), nil
+}
+
+// ParseRating parses a star rating such as "4.9" or "4,9/5"
+func ParseRating(text string) (float64, error) {
+	match := ratingPattern.FindString(text)
+	if match == "" {
+		return 0, fmt.Errorf("no rating in %q", text)
+	}
+	return strconv.ParseFloat(strings.ReplaceAll(match, ",", "."), 64)
+}
+
+// apiItem is the subset of Shopee's item JSON the bot reads
+type apiItem struct {
+	ItemID              int64  `json:"itemid"`
+	ShopID              int64  `json:"shopid"`
+	Name                string `json:"name"`
+	Image               string `json:"image"`
+	Price               int64  `json:"price"`
+	PriceMin            int64  `json:"price_min"`
+	PriceMax            int64  `json:"price_max"`
+	PriceBeforeDiscount int64  `json:"price_before_discount"`
+	RawDiscount         int    `json:"raw_discount"`
+	Stock               *int   `json:"stock"`
+	Sold                int    `json:"sold"`
+	HistoricalSold      int    `json:"historical_sold"`
+	ItemRating          struct {
+		RatingStar float64 `json:"rating_star"`
+	} `json:"item_rating"`
+	Models []struct {
+		ModelID int64 `json:"modelid"`
+	} `json:"models"`
+}
+
+// ParseAPIItems finds every item object in a Shopee API response body.
+// Item objects are recognised by their itemid and shopid fields wherever
+// they appear in the payload.
+func ParseAPIItems(body []byte, c region.Currency, imageBase string) []*Snapshot {
+	var root interface{}
+	if err := json.Unmarshal(body, &root); err != nil {
+		return nil
+	}
+
+	var snapshots []*Snapshot
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch node := v.(type) {
+		case map[string]interface{}:
+			if _, ok := node["itemid"]; ok {
+				if _, ok := node["shopid"]; ok {
+					if snap := apiSnapshot(node, c, imageBase); snap != nil {
+						snapshots = append(snapshots, snap)
+					}
+				}
+			}
+			for _, child := range node {
+				walk(child)
+			}
+		case []interface{}:
+			for _, child := range node {
+				walk(child)
+			}
+		}
+	}
+	walk(root)
+
+	return snapshots
+}
+
+func apiSnapshot(node map[string]interface{}, c region.Currency, imageBase string) *Snapshot {
+	raw, err := json.Marshal(node)
+	if err != nil {
+		return nil
+	}
+	var item apiItem
+	if err := json.Unmarshal(raw, &item); err != nil || item.ItemID == 0 {
+		return nil
+	}
+
+	snap := &Snapshot{
+		ItemID: item.ItemID,
+		ShopID: item.ShopID,
+		Title:  item.Name,
+		Sold:   item.HistoricalSold,
+		Rating: item.ItemRating.RatingStar,
+		Source: SourceAPI,
+	}
+	if snap.Sold == 0 {
+		snap.Sold = item.Sold
+	}
+	if item.Image != "" {
+		snap.ImageURL = imageBase + item.Image
+	}
+	for _, m := range item.Models {
+		snap.ModelIDs = append(snap.ModelIDs, m.ModelID)
+	}
+
+	low, high := item.PriceMin, item.PriceMax
+	if low == 0 {
+		low = item.Price
+	}
+	if high < low {
+		high = low
+	}
+	snap.Price = Price{
+		Current:         APIMoney(low, c),
+		Max:             APIMoney(high, c),
+		DiscountPercent: item.RawDiscount,
+	}
+	if item.PriceBeforeDiscount > 0 {
+		snap.Price.Original = APIMoney(item.PriceBeforeDiscount, c)
+	}
+
+	if item.Stock != nil {
+		snap.Stock = Stock{Quantity: *item.Stock, SoldOut: *item.Stock == 0, Known: true}
+	}
+
+	return snap
+}
+
+// APIMoney converts an API price (currency units x 100000) into minor units
+func APIMoney(value int64, c region.Currency) Money {
+	amount := value
+	for i := 0; i < c.MinorDigits; i++ {
+		amount *= 10
+	}
+	return Money{Amount: amount / apiPriceScale, Currency: c}
+}
+
+// SnapshotStore persists snapshots as JSON with an optional product card image
+type SnapshotStore struct {
+	dir string
+}
+
+// NewSnapshotStore creates a store rooted at dir
+func NewSnapshotStore(dir string) *SnapshotStore {
+	return &SnapshotStore{dir: dir}
+}
+
+// Save writes the snapshot (and its screenshot, if any) under
+// <dir>/<shop>.<item>/<timestamp>.json and returns the JSON path
+func (s *SnapshotStore) Save(snap *Snapshot, screenshot []byte) (string, error) {
+	dir := filepath.Join(s.dir, sanitize(snap.Key()))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+
+	base := filepath.Join(dir, snap.CapturedAt.Format("20060102-150405.000"))
+	if len(screenshot) > 0 {
+		if err := os.WriteFile(base+".png", screenshot, 0644); err != nil {
+			return "", fmt.Errorf("failed to write snapshot image: %w", err)
+		}
+		snap.Screenshot = base + ".png"
+	}
+
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal snapshot: %w", err)
+	}
+	if err := os.WriteFile(base+".json", data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	return base + ".json", nil
+}
+
+// sanitize makes a product key safe to use as a directory name
+func sanitize(key string) string {
+	safe := unsafeChars.ReplaceAllString(key, "_")
+	if len(safe) > 80 {
+		safe = safe[:80]
+	}
+	if safe == "" {
+		safe = "unknown"
+	}
+	return safe
+}
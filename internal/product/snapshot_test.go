@@ -0,0 +1,73 @@
+package product
+
+import "testing"
+
+func TestParseSold(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"1,234 sold", 1234},
+		{"1.2k sold", 1200},
+		{"ขายแล้ว 3.4พัน ชิ้น", 3400},
+		{"10RB+ terjual", 10000},
+		{"2,5mil vendidos", 2500},
+		{"87 sold", 87},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got, err := ParseSold(tt.text)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseItemURL(t *testing.T) {
+	tests := []struct {
+		url            string
+		shopID, itemID int64
+		ok             bool
+	}{
+		{"https://shopee.co.th/Some-Product-i.123456.987654321?sp_atk=x", 123456, 987654321, true},
+		{"https://shopee.co.th/product/123456/987654321", 123456, 987654321, true},
+		{"https://shopee.co.th/cart", 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		shopID, itemID, ok := ParseItemURL(tt.url)
+		if shopID != tt.shopID || itemID != tt.itemID || ok != tt.ok {
+			t.Errorf("%s: got %d/%d/%v", tt.url, shopID, itemID, ok)
+		}
+	}
+}
+
+func TestParseAPIItems(t *testing.T) {
+	body := []byte(`{"data":{"items":[{"item_basic":{
+		"itemid": 987, "shopid": 123, "name": "Lip Tint", "image": "abc",
+		"price_min": 9900000, "price_max": 14900000, "price_before_discount": 19800000,
+		"raw_discount": 50, "stock": 12, "historical_sold": 3400,
+		"item_rating": {"rating_star": 4.8}, "models": [{"modelid": 1}, {"modelid": 2}]
+	}}]}}`)
+
+	snaps := ParseAPIItems(body, currency(t, "th"), "https://img/")
+	if len(snaps) != 1 {
+		t.Fatalf("got %d snapshots, want 1", len(snaps))
+	}
+
+	s := snaps[0]
+	if s.ItemID != 987 || s.ShopID != 123 || s.Title != "Lip Tint" || s.ImageURL != "https://img/abc" {
+		t.Errorf("unexpected identity: %+v", s)
+	}
+	if s.Price.Current.Amount != 9900 || s.Price.Max.Amount != 14900 || s.Price.Original.Amount != 19800 {
+		t.Errorf("unexpected price: %+v", s.Price)
+	}
+	if s.Stock.Quantity != 12 || s.Sold != 3400 || s.Rating != 4.8 || len(s.ModelIDs) != 2 {
+		t.Errorf("unexpected details: %+v", s)
+	}
+}
@@ -0,0 +1,282 @@
+package purchase
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/LLionNg/shopee-livestream-bot/internal/config"
+	"github.com/LLionNg/shopee-livestream-bot/internal/product"
+)
+
+var (
+	// ErrOverBudget means the purchase would pass purchase.budget
+	ErrOverBudget = errors.New("purchase budget exceeded")
+	// ErrExpired means the intent's deadline passed before its turn came
+	ErrExpired = errors.New("purchase deadline passed while queued")
+)
+
+// Intent is a stream's request to add the product shown on its tab to the
+// cart
+type Intent struct {
+	StreamID int
+	Selector string // add-to-cart button on the stream's tab
+	Name     string
+	ItemID   int64
+	Price    product.Money // unit price, zero when it couldn't be read
+	Deadline time.Time     // drop the intent if its turn comes later
+}
+
+// Result is what the dispatcher reports back to the stream
+type Result struct {
+	Priority int
+	Waited   time.Duration
+	Err      error
+}
+
+// Dispatcher takes purchase intents from every stream and runs them in
+// order of product priority, then deadline, at most purchase.concurrency at
+// a time. The purchase budget is checked and held under the same lock, so
+// streams firing together can't overspend it.
+type Dispatcher struct {
+	cfg  *config.Config
+	exec *Executor
+	buy  func(ctx context.Context, selector string) error
+
+	mu      sync.Mutex
+	queue   intentQueue
+	active  map[string]*queued // queued or running intents by stream and item
+	seq     uint64
+	running int
+	spent   int64 // added to the cart since start, in minor units
+	pending int64 // held by purchases in progress
+}
+
+// NewDispatcher creates a dispatcher that buys through exec
+func NewDispatcher(cfg *config.Config, exec *Executor) *Dispatcher {
+	return &Dispatcher{
+		cfg:  cfg,
+		exec: exec,
+		buy:  exec.ExecutePurchase,
+	}
+}
+
+// Paused returns why purchasing is paused, or "" when it isn't
+func (d *Dispatcher) Paused() string {
+	return d.exec.Paused()
+}
+
+// Spent returns the value added to the cart since the bot started
+func (d *Dispatcher) Spent() product.Money {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return product.Money{Amount: d.spent, Currency: d.cfg.Shopee.GetRegion().Currency}
+}
+
+// Left returns how much of purchase.budget is neither added nor in
+// progress; ok is false without a budget
+func (d *Dispatcher) Left() (left product.Money, ok bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	budget := d.budget()
+	left = product.Money{Amount: budget - d.spent - d.pending, Currency: d.cfg.Shopee.GetRegion().Currency}
+	return left, budget > 0
+}
+
+// budget is purchase.budget in minor units, 0 without one
+func (d *Dispatcher) budget() int64 {
+	return int64(math.Round(d.cfg.Purchase.Budget * math.Pow10(d.cfg.Shopee.GetRegion().Currency.MinorDigits)))
+}
+
+// Submit queues the intent and waits for its result. ctx is the stream's
+// tab; the purchase runs there, and cancelling it withdraws a queued intent.
+// An intent for a product the stream already has queued or in progress
+// joins that one and gets its result instead of buying the product again.
+func (d *Dispatcher) Submit(ctx context.Context, in Intent) Result {
+	now := time.Now()
+	if in.Deadline.IsZero() {
+		in.Deadline = now.Add(d.cfg.Purchase.GetQueueWait())
+	}
+	key := in.key()
+
+	d.mu.Lock()
+	if q, ok := d.active[key]; ok && key != "" {
+		d.mu.Unlock()
+		select {
+		case <-q.done:
+			return q.result
+		case <-ctx.Done():
+			return Result{Priority: q.priority, Waited: time.Since(now), Err: ctx.Err()}
+		}
+	}
+	q := &queued{
+		Intent:   in,
+		ctx:      ctx,
+		key:      key,
+		priority: d.cfg.Purchase.PriorityFor(in.Name, in.ItemID),
+		added:    now,
+		done:     make(chan struct{}),
+	}
+	d.seq++
+	q.seq = d.seq
+	if key != "" {
+		if d.active == nil {
+			d.active = make(map[string]*queued)
+		}
+		d.active[key] = q
+	}
+	heap.Push(&d.queue, q)
+	d.dispatch()
+	d.mu.Unlock()
+
+	select {
+	case <-q.done:
+		return q.result
+	case <-ctx.Done():
+	}
+
+	d.mu.Lock()
+	if q.index >= 0 {
+		heap.Remove(&d.queue, q.index)
+		d.finish(q, Result{Priority: q.priority, Waited: time.Since(q.added), Err: ctx.Err()})
+	}
+	d.mu.Unlock()
+
+	// Already started, the purchase stops with the tab
+	<-q.done
+	return q.result
+}
+
+// dispatch starts queued intents while there is capacity; the caller holds
+// d.mu
+func (d *Dispatcher) dispatch() {
+	for d.running < d.cfg.Purchase.Concurrency && d.queue.Len() > 0 {
+		q := heap.Pop(&d.queue).(*queued)
+		waited := time.Since(q.added)
+
+		var err error
+		switch {
+		case q.ctx.Err() != nil:
+			err = q.ctx.Err()
+		case time.Now().After(q.Deadline):
+			err = fmt.Errorf("%w (waited %s)", ErrExpired, waited.Round(time.Millisecond))
+		default:
+			err = d.fits(q.Intent)
+		}
+		if err != nil {
+			d.finish(q, Result{Priority: q.priority, Waited: waited, Err: err})
+			continue
+		}
+
+		amount := q.Price.Amount
+		d.pending += amount
+		d.running++
+		go d.run(q, amount, waited)
+	}
+}
+
+// run buys one intent and hands its slot to the next
+func (d *Dispatcher) run(q *queued, amount int64, waited time.Duration) {
+	err := d.buy(q.ctx, q.Selector)
+
+	d.mu.Lock()
+	d.running--
+	d.pending -= amount
+	if err == nil {
+		d.spent += amount
+	}
+	d.finish(q, Result{Priority: q.priority, Waited: waited, Err: err})
+	d.dispatch()
+	d.mu.Unlock()
+}
+
+// finish reports the intent's result to everyone waiting on it; the caller
+// holds d.mu
+func (d *Dispatcher) finish(q *queued, r Result) {
+	if d.active[q.key] == q {
+		delete(d.active, q.key)
+	}
+	q.result = r
+	close(q.done)
+}
+
+// fits checks the intent against the budget; the caller holds d.mu
+func (d *Dispatcher) fits(in Intent) error {
+	currency := d.cfg.Shopee.GetRegion().Currency
+	budget := d.budget()
+	if budget <= 0 {
+		return nil
+	}
+	if in.Price.Amount <= 0 {
+		return fmt.Errorf("%w: price of %q unknown", ErrOverBudget, in.Name)
+	}
+	if used := d.spent + d.pending; used+in.Price.Amount > budget {
+		return fmt.Errorf("%w: %s added or in progress, %s more would pass %s", ErrOverBudget, currency.Format(used), currency.Format(in.Price.Amount), currency.Format(budget))
+	}
+	return nil
+}
+
+// queued is an intent waiting in the dispatcher's queue
+type queued struct {
+	Intent
+	ctx      context.Context
+	priority int
+	seq      uint64
+	added    time.Time
+	key      string
+	index    int           // position in the heap, -1 once taken
+	done     chan struct{} // closed once result is set
+	result   Result
+}
+
+// key identifies the product on the stream, "" when the intent names none
+func (in Intent) key() string {
+	switch {
+	case in.ItemID != 0:
+		return fmt.Sprintf("%d/%d", in.StreamID, in.ItemID)
+	case in.Name != "":
+		return fmt.Sprintf("%d/%q", in.StreamID, in.Name)
+	}
+	return ""
+}
+
+// intentQueue is a heap of intents, highest priority and earliest deadline
+// first, then first come
+type intentQueue []*queued
+
+func (q intentQueue) Len() int { return len(q) }
+
+func (q intentQueue) Less(i, j int) bool {
+	if q[i].priority != q[j].priority {
+		return q[i].priority > q[j].priority
+	}
+	if !q[i].Deadline.Equal(q[j].Deadline) {
+		return q[i].Deadline.Before(q[j].Deadline)
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q intentQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *intentQueue) Push(x interface{}) {
+	item := x.(*queued)
+	item.index = len(*q)
+	*q = append(*q, item)
+}
+
+func (q *intentQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	old[len(old)-1] = nil
+	item.index = -1
+	*q = old[:len(old)-1]
+	return item
+}
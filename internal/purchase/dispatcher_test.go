@@ -0,0 +1,244 @@
+package purchase
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/LLionNg/shopee-livestream-bot/internal/config"
+	"github.com/LLionNg/shopee-livestream-bot/internal/product"
+)
+
+func testDispatcher(t *testing.T, p config.PurchaseConfig) *Dispatcher {
+	t.Helper()
+	cfg := &config.Config{
+		Shopee:   config.ShopeeConfig{Region: "th", LivestreamURLs: []string{"https://th.shp.ee/test"}},
+		Purchase: p,
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	return &Dispatcher{cfg: cfg}
+}
+
+// waitQueued waits until n intents are queued
+func waitQueued(t *testing.T, d *Dispatcher, n int) {
+	t.Helper()
+	for i := 0; i < 200; i++ {
+		d.mu.Lock()
+		l := d.queue.Len()
+		d.mu.Unlock()
+		if l == n {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("queue never reached %d intents", n)
+}
+
+func TestDispatcherOrder(t *testing.T) {
+	d := testDispatcher(t, config.PurchaseConfig{
+		Priorities: []config.ProductPriority{{Match: "lamp", Priority: 10}, {Match: "777", Priority: 5}},
+	})
+
+	gate := make(chan struct{})
+	var mu sync.Mutex
+	var order []string
+	d.buy = func(ctx context.Context, selector string) error {
+		if selector == "first" {
+			<-gate
+		}
+		mu.Lock()
+		order = append(order, selector)
+		mu.Unlock()
+		return nil
+	}
+
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	submit := func(in Intent) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if res := d.Submit(ctx, in); res.Err != nil {
+				t.Errorf("%s: %v", in.Selector, res.Err)
+			}
+		}()
+	}
+
+	// "first" holds the only slot while the others queue up
+	submit(Intent{Selector: "first"})
+	waitQueued(t, d, 0)
+	time.Sleep(10 * time.Millisecond)
+
+	soon := time.Now().Add(time.Second)
+	submit(Intent{Selector: "plain-late", StreamID: 1, Name: "Mug"})
+	waitQueued(t, d, 1)
+	submit(Intent{Selector: "plain-soon", StreamID: 2, Name: "Mug", Deadline: soon})
+	waitQueued(t, d, 2)
+	submit(Intent{Selector: "by-id", Name: "Cup", ItemID: 777})
+	waitQueued(t, d, 3)
+	submit(Intent{Selector: "lamp", Name: "Desk LAMP"})
+	waitQueued(t, d, 4)
+
+	close(gate)
+	wg.Wait()
+
+	want := []string{"first", "lamp", "by-id", "plain-soon", "plain-late"}
+	if len(order) != len(want) {
+		t.Fatalf("order = %v, want %v", order, want)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("order = %v, want %v", order, want)
+		}
+	}
+}
+
+func TestDispatcherConcurrency(t *testing.T) {
+	d := testDispatcher(t, config.PurchaseConfig{Concurrency: 2})
+
+	var mu sync.Mutex
+	running, peak := 0, 0
+	d.buy = func(ctx context.Context, selector string) error {
+		mu.Lock()
+		running++
+		if running > peak {
+			peak = running
+		}
+		mu.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.Submit(context.Background(), Intent{})
+		}()
+	}
+	wg.Wait()
+
+	if peak != 2 {
+		t.Errorf("peak concurrency = %d, want 2", peak)
+	}
+}
+
+func TestDispatcherBudget(t *testing.T) {
+	d := testDispatcher(t, config.PurchaseConfig{Concurrency: 4, Budget: 300})
+
+	fail := errors.New("button gone")
+	d.buy = func(ctx context.Context, selector string) error {
+		if selector == "fail" {
+			return fail
+		}
+		return nil
+	}
+	price := func(amount int64) product.Money { return product.Money{Amount: amount} }
+	ctx := context.Background()
+
+	tests := []struct {
+		in      Intent
+		wantErr error
+	}{
+		{Intent{Selector: "ok", Price: price(20000)}, nil},
+		{Intent{Selector: "fail", Price: price(10000)}, fail},        // failed buys don't count
+		{Intent{Selector: "ok", Price: price(15000)}, ErrOverBudget}, // 200 + 150 > 300
+		{Intent{Selector: "ok", Price: price(10000)}, nil},           // exactly 300
+		{Intent{Selector: "ok", Name: "no price"}, ErrOverBudget},    // can't be checked
+		{Intent{Selector: "ok", Deadline: time.Now().Add(-time.Second)}, ErrExpired},
+	}
+	for i, tt := range tests {
+		res := d.Submit(ctx, tt.in)
+		if !errors.Is(res.Err, tt.wantErr) {
+			t.Errorf("%d: err = %v, want %v", i, res.Err, tt.wantErr)
+		}
+	}
+	if got := d.Spent().Amount; got != 30000 {
+		t.Errorf("spent = %d, want 30000", got)
+	}
+}
+
+func TestDispatcherCancelQueued(t *testing.T) {
+	d := testDispatcher(t, config.PurchaseConfig{})
+
+	gate := make(chan struct{})
+	d.buy = func(ctx context.Context, selector string) error {
+		<-gate
+		return nil
+	}
+	go d.Submit(context.Background(), Intent{})
+	time.Sleep(10 * time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan Result, 1)
+	go func() { done <- d.Submit(ctx, Intent{}) }()
+	waitQueued(t, d, 1)
+	cancel()
+
+	if res := <-done; !errors.Is(res.Err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", res.Err)
+	}
+	waitQueued(t, d, 0)
+	close(gate)
+}
+
+func TestDispatcherCollapsesDuplicates(t *testing.T) {
+	d := testDispatcher(t, config.PurchaseConfig{Concurrency: 2})
+
+	gate := make(chan struct{})
+	var mu sync.Mutex
+	bought := map[string]int{}
+	d.buy = func(ctx context.Context, selector string) error {
+		<-gate
+		mu.Lock()
+		bought[selector]++
+		mu.Unlock()
+		return nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([]Result, 4)
+	submit := func(i int, in Intent) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = d.Submit(context.Background(), in)
+		}()
+	}
+
+	// the first takes a slot, the repeat joins it while it runs
+	submit(0, Intent{Selector: "first", StreamID: 1, ItemID: 42})
+	waitQueued(t, d, 0)
+	time.Sleep(10 * time.Millisecond)
+	submit(1, Intent{Selector: "repeat", StreamID: 1, ItemID: 42})
+	// the same item on another stream is bought separately
+	submit(2, Intent{Selector: "other-stream", StreamID: 2, ItemID: 42})
+	time.Sleep(10 * time.Millisecond)
+	// both slots are busy now, a later repeat still joins the first
+	submit(3, Intent{Selector: "repeat-again", StreamID: 1, ItemID: 42})
+	time.Sleep(10 * time.Millisecond)
+
+	close(gate)
+	wg.Wait()
+
+	want := map[string]int{"first": 1, "other-stream": 1}
+	if len(bought) != len(want) || bought["first"] != 1 || bought["other-stream"] != 1 {
+		t.Errorf("bought = %v, want %v", bought, want)
+	}
+	for i, r := range results {
+		if r.Err != nil {
+			t.Errorf("%d: err = %v", i, r.Err)
+		}
+	}
+	if results[1] != results[0] || results[3] != results[0] {
+		t.Errorf("repeats got %+v and %+v, want the first's %+v", results[1], results[3], results[0])
+	}
+}
@@ -2,122 +2,166 @@ package purchase
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 
-	"github.com/chromedp/chromedp"
+	"github.com/LLionNg/shopee-livestream-bot/internal/cart"
 	"github.com/LLionNg/shopee-livestream-bot/internal/config"
+	"github.com/LLionNg/shopee-livestream-bot/internal/console"
+	"github.com/LLionNg/shopee-livestream-bot/internal/evidence"
+	"github.com/LLionNg/shopee-livestream-bot/internal/product"
+	"github.com/LLionNg/shopee-livestream-bot/internal/region"
+	"github.com/LLionNg/shopee-livestream-bot/internal/selectors"
+	"github.com/chromedp/chromedp"
 )
 
-// Executor handles the purchase execution flow
+// ErrPaused is returned while purchasing is paused, e.g. during re-login
+var ErrPaused = errors.New("purchasing paused")
+
+// Executor handles the purchase execution flow. Every method runs against
+// the tab passed in ctx, so one executor serves all stream tabs.
 type Executor struct {
-	ctx context.Context
-	cfg *config.Config
+	cfg      *config.Config
+	sel      *selectors.Store
+	evidence *evidence.Collector
+
+	mu     sync.Mutex
+	paused string
 }
 
 // NewExecutor creates a new purchase executor
-func NewExecutor(ctx context.Context, cfg *config.Config) *Executor {
+func NewExecutor(cfg *config.Config, sel *selectors.Store, ev *evidence.Collector) *Executor {
 	return &Executor{
-		ctx: ctx,
-		cfg: cfg,
+		cfg:      cfg,
+		sel:      sel,
+		evidence: ev,
 	}
 }
 
+// Pause stops new purchases until Resume is called
+func (e *Executor) Pause(reason string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.paused = reason
+}
+
+// Resume allows purchases again
+func (e *Executor) Resume() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.paused = ""
+}
+
+// Paused returns why purchasing is paused, or "" when it isn't
+func (e *Executor) Paused() string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.paused
+}
+
 // ExecutePurchase adds the product to cart (items are auto-reserved once in cart)
-func (e *Executor) ExecutePurchase(productSelector string) error {
-	fmt.Println("🛒 Adding item to cart...")
+func (e *Executor) ExecutePurchase(ctx context.Context, productSelector string) error {
+	if reason := e.Paused(); reason != "" {
+		return fmt.Errorf("%w: %s", ErrPaused, reason)
+	}
+
+	console.Println("🛒 Adding item to cart...")
 
 	// Add to cart - items are automatically reserved during livestream
-	if err := e.AddToCart(productSelector); err != nil {
+	err := e.AddToCart(ctx, productSelector)
+
+	// Keep evidence of every attempt, successful or not
+	reason := "purchase-success"
+	if err != nil {
+		reason = "purchase-failure"
+	}
+	if dir, captureErr := e.evidence.Capture(ctx, evidence.Info{Reason: reason}, err); captureErr != nil {
+		console.Printf("⚠️  Evidence capture incomplete (%s): %v\n", dir, captureErr)
+	}
+
+	if err != nil {
+		if e.soldOut(ctx) {
+			return fmt.Errorf("failed to add to cart: %w", ErrSoldOut)
+		}
 		return fmt.Errorf("failed to add to cart: %w", err)
 	}
 
-	fmt.Println("✅ Item successfully added to cart and reserved!")
+	console.Println("✅ Item successfully added to cart and reserved!")
 
 	return nil
 }
 
 // AddToCart adds the product to the cart
-func (e *Executor) AddToCart(selector string) error {
+func (e *Executor) AddToCart(ctx context.Context, selector string) error {
 	// Wait for the button to be clickable
-	ctx, cancel := context.WithTimeout(e.ctx, 5*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
-	
+
 	// Click the add to cart button
 	err := chromedp.Run(ctx,
 		chromedp.WaitVisible(selector, chromedp.ByQuery),
 		chromedp.Click(selector, chromedp.ByQuery),
 	)
-	
+
+	if errors.Is(err, context.DeadlineExceeded) && ctx.Err() != nil {
+		return fmt.Errorf("%w: %v", ErrClickTimeout, err)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to click add to cart: %w", err)
 	}
-	
+
 	// Wait for cart update animation
-	time.Sleep(1 * time.Second)
-	
+	select {
+	case <-ctx.Done():
+	case <-time.After(1 * time.Second):
+	}
+
 	return nil
 }
 
+// RetryPurchase adds to cart under the configured retry policy
+func (e *Executor) RetryPurchase(ctx context.Context, productSelector string) error {
+	return NewRetryPolicy(e.cfg.Purchase).Do(ctx, func(ctx context.Context, attempt int) error {
+		return e.ExecutePurchase(ctx, productSelector)
+	})
+}
 
-// RetryPurchase retries adding to cart with exponential backoff
-func (e *Executor) RetryPurchase(productSelector string) error {
-	maxRetries := e.cfg.Purchase.MaxRetries
-	retryDelay := e.cfg.Purchase.GetRetryDelay()
-
-	var lastErr error
-	for i := 0; i < maxRetries; i++ {
-		if i > 0 {
-			waitTime := retryDelay * time.Duration(i)
-			fmt.Printf("🔄 Retry %d/%d after %v...\n", i+1, maxRetries, waitTime)
-			time.Sleep(waitTime)
-		}
-
-		err := e.ExecutePurchase(productSelector)
-		if err == nil {
-			return nil
-		}
-
-		lastErr = err
-		fmt.Printf("⚠️  Attempt %d failed: %v\n", i+1, err)
+// soldOut reports whether the page shows the product as sold out
+func (e *Executor) soldOut(ctx context.Context) bool {
+	text, err := e.sel.Text(ctx, selectors.ProductStock)
+	if err != nil {
+		return false
 	}
-
-	return fmt.Errorf("all %d attempts to add to cart failed: %w", maxRetries, lastErr)
+	stock, err := product.ParseStock(text, e.cfg.Shopee.GetRegion().Texts[region.TextSoldOut])
+	return err == nil && !stock.Available()
 }
 
 // GetCartItemCount returns the number of items in cart
-func (e *Executor) GetCartItemCount() (int, error) {
+func (e *Executor) GetCartItemCount(ctx context.Context) (int, error) {
+	selector, err := e.sel.Find(ctx, selectors.CartCount)
+	if errors.Is(err, selectors.ErrNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	quoted, _ := json.Marshal(selector)
 	var count int
-	err := chromedp.Run(e.ctx,
-		chromedp.Evaluate(`parseInt(document.querySelector('[class*="cart-count"]')?.innerText || '0')`, &count),
+	err = chromedp.Run(ctx,
+		chromedp.Evaluate(fmt.Sprintf(`parseInt(document.querySelector(%s)?.innerText || '0')`, quoted), &count),
 	)
 	return count, err
 }
 
 // ClearCart removes all items from the cart
-func (e *Executor) ClearCart() error {
-	// Navigate to cart
-	cartURL := e.cfg.Shopee.BaseURL + "/cart"
-	if err := chromedp.Run(e.ctx, chromedp.Navigate(cartURL)); err != nil {
-		return fmt.Errorf("failed to navigate to cart: %w", err)
+func (e *Executor) ClearCart(ctx context.Context) error {
+	if err := cart.NewManager(e.cfg, e.sel).Clear(ctx); err != nil {
+		return err
 	}
-
-	time.Sleep(2 * time.Second) // Wait for cart page to load
-
-	// Select all items and delete
-	err := chromedp.Run(e.ctx,
-		chromedp.Click("input[type='checkbox'][class*='select-all']", chromedp.ByQuery),
-		chromedp.Sleep(500*time.Millisecond),
-		chromedp.Click("button[class*='delete']", chromedp.ByQuery),
-		chromedp.Sleep(500*time.Millisecond),
-		chromedp.Click("button[class*='confirm']", chromedp.ByQuery),
-	)
-
-	if err != nil {
-		return fmt.Errorf("failed to clear cart: %w", err)
-	}
-
-	fmt.Println("🗑️  Cart cleared successfully")
+	console.Println("🗑️  Cart cleared successfully")
 	return nil
 }
-
@@ -0,0 +1,146 @@
+package purchase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/LLionNg/shopee-livestream-bot/internal/config"
+	"github.com/LLionNg/shopee-livestream-bot/internal/console"
+)
+
+var (
+	// ErrSoldOut means the product can't be bought any more; retrying won't help
+	ErrSoldOut = errors.New("product sold out")
+	// ErrClickTimeout means the add-to-cart button didn't respond in time
+	ErrClickTimeout = errors.New("add to cart timed out")
+)
+
+// Backoff strategies for purchase.backoff
+const (
+	BackoffConstant    = "constant"
+	BackoffLinear      = "linear"
+	BackoffExponential = "exponential"
+	BackoffJitter      = "exponential-jitter"
+)
+
+// RetryPolicy decides how often and how long to wait between attempts
+type RetryPolicy struct {
+	Attempts   int           // total attempts, including the first
+	Backoff    string        // one of the Backoff* strategies
+	Delay      time.Duration // base delay
+	MaxDelay   time.Duration // cap on a single wait, 0 for none
+	MaxElapsed time.Duration // give up once this much time has passed, 0 for none
+	Retryable  func(error) bool
+
+	rand func() float64
+}
+
+// NewRetryPolicy builds the policy from the purchase config
+func NewRetryPolicy(cfg config.PurchaseConfig) RetryPolicy {
+	return RetryPolicy{
+		Attempts:   cfg.MaxRetries,
+		Backoff:    cfg.Backoff,
+		Delay:      cfg.GetRetryDelay(),
+		MaxDelay:   cfg.GetMaxDelay(),
+		MaxElapsed: cfg.GetMaxElapsed(),
+		Retryable:  Retryable,
+	}
+}
+
+// Retryable reports whether another attempt could succeed. A sold-out
+// product, paused purchasing, a refused or expired queue entry and a
+// cancelled context are final; anything else, e.g. a click timeout, is
+// worth another try.
+func Retryable(err error) bool {
+	switch {
+	case errors.Is(err, ErrSoldOut),
+		errors.Is(err, ErrPaused),
+		errors.Is(err, ErrOverBudget),
+		errors.Is(err, ErrExpired),
+		errors.Is(err, context.Canceled):
+		return false
+	}
+	return true
+}
+
+// Wait returns the delay before retry n (1 for the first retry). Without a
+// MaxDelay it still stops growing at the longest time.Duration.
+func (p RetryPolicy) Wait(n int) time.Duration {
+	var f float64
+	switch p.Backoff {
+	case BackoffConstant:
+		f = float64(p.Delay)
+	case BackoffExponential, BackoffJitter:
+		f = float64(p.Delay) * math.Pow(2, float64(n-1))
+	default:
+		f = float64(p.Delay) * float64(n)
+	}
+
+	// Clamp in floating point; the conversion overflows past the limit
+	d := p.MaxDelay
+	if d <= 0 {
+		d = math.MaxInt64
+	}
+	if f < float64(d) {
+		d = time.Duration(f)
+	}
+	if p.Backoff == BackoffJitter {
+		// Half fixed, half random, so streams retrying together spread out
+		r := rand.Float64
+		if p.rand != nil {
+			r = p.rand
+		}
+		d = d/2 + time.Duration(r()*float64(d/2))
+	}
+	return d
+}
+
+// Do calls fn until it succeeds, fails with an error that isn't retryable,
+// runs out of attempts or time, or ctx is done. fn gets the attempt number,
+// starting at 1.
+func (p RetryPolicy) Do(ctx context.Context, fn func(ctx context.Context, attempt int) error) error {
+	attempts := p.Attempts
+	if attempts <= 0 {
+		attempts = 1
+	}
+	retryable := p.Retryable
+	if retryable == nil {
+		retryable = Retryable
+	}
+	start := time.Now()
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = fn(ctx, attempt)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return err
+		}
+		if !retryable(err) {
+			return err
+		}
+		if attempt >= attempts {
+			return fmt.Errorf("all %d attempts failed: %w", attempts, err)
+		}
+
+		wait := p.Wait(attempt)
+		if p.MaxElapsed > 0 && time.Since(start)+wait > p.MaxElapsed {
+			return fmt.Errorf("gave up after %s and %d attempts: %w", time.Since(start).Round(time.Millisecond), attempt, err)
+		}
+		console.Printf("🔄 Retry %d/%d in %v after: %v\n", attempt+1, attempts, wait.Round(time.Millisecond), err)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package purchase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestRetryWait(t *testing.T) {
+	base := 100 * time.Millisecond
+	tests := []struct {
+		backoff string
+		max     time.Duration
+		want    []time.Duration
+	}{
+		{BackoffConstant, 0, []time.Duration{base, base, base, base}},
+		{BackoffLinear, 0, []time.Duration{base, 2 * base, 3 * base, 4 * base}},
+		{BackoffExponential, 0, []time.Duration{base, 2 * base, 4 * base, 8 * base}},
+		{BackoffExponential, 300 * time.Millisecond, []time.Duration{base, 2 * base, 3 * base, 3 * base}},
+		// rand fixed at 0.5: three quarters of the exponential delay
+		{BackoffJitter, 0, []time.Duration{75 * time.Millisecond, 150 * time.Millisecond, 300 * time.Millisecond, 600 * time.Millisecond}},
+	}
+	for _, tt := range tests {
+		p := RetryPolicy{Backoff: tt.backoff, Delay: base, MaxDelay: tt.max, rand: func() float64 { return 0.5 }}
+		for i, want := range tt.want {
+			if got := p.Wait(i + 1); got != want {
+				t.Errorf("%s max %s: Wait(%d) = %s, want %s", tt.backoff, tt.max, i+1, got, want)
+			}
+		}
+	}
+}
+
+func TestRetryWaitLarge(t *testing.T) {
+	for _, backoff := range []string{BackoffLinear, BackoffExponential, BackoffJitter} {
+		for _, max := range []time.Duration{0, time.Minute} {
+			p := RetryPolicy{Backoff: backoff, Delay: time.Second, MaxDelay: max, rand: func() float64 { return 0.5 }}
+			prev := time.Duration(0)
+			for _, n := range []int{30, 64, 100, 1 << 20, math.MaxInt32} {
+				got := p.Wait(n)
+				if got < prev || (max > 0 && got > max) {
+					t.Errorf("%s max %s: Wait(%d) = %s after %s", backoff, max, n, got, prev)
+				}
+				prev = got
+			}
+		}
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{fmt.Errorf("failed to add to cart: %w", ErrSoldOut), false},
+		{fmt.Errorf("%w: session expired", ErrPaused), false},
+		{fmt.Errorf("%w: over", ErrOverBudget), false},
+		{ErrExpired, false},
+		{context.Canceled, false},
+		{fmt.Errorf("%w: context deadline exceeded", ErrClickTimeout), true},
+		{errors.New("node not found"), true},
+	}
+	for _, tt := range tests {
+		if got := Retryable(tt.err); got != tt.want {
+			t.Errorf("Retryable(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestRetryDo(t *testing.T) {
+	p := RetryPolicy{Attempts: 4, Backoff: BackoffConstant, Delay: time.Millisecond}
+	ctx := context.Background()
+
+	t.Run("succeeds after retries", func(t *testing.T) {
+		calls := 0
+		err := p.Do(ctx, func(ctx context.Context, attempt int) error {
+			calls++
+			if attempt < 3 {
+				return ErrClickTimeout
+			}
+			return nil
+		})
+		if err != nil || calls != 3 {
+			t.Errorf("err = %v after %d calls, want success after 3", err, calls)
+		}
+	})
+
+	t.Run("stops on sold out", func(t *testing.T) {
+		calls := 0
+		err := p.Do(ctx, func(ctx context.Context, attempt int) error {
+			calls++
+			return ErrSoldOut
+		})
+		if !errors.Is(err, ErrSoldOut) || calls != 1 {
+			t.Errorf("err = %v after %d calls, want ErrSoldOut after 1", err, calls)
+		}
+	})
+
+	t.Run("runs out of attempts", func(t *testing.T) {
+		calls := 0
+		err := p.Do(ctx, func(ctx context.Context, attempt int) error {
+			calls++
+			return ErrClickTimeout
+		})
+		if !errors.Is(err, ErrClickTimeout) || calls != 4 {
+			t.Errorf("err = %v after %d calls, want ErrClickTimeout after 4", err, calls)
+		}
+	})
+
+	t.Run("max elapsed", func(t *testing.T) {
+		p := RetryPolicy{Attempts: 100, Backoff: BackoffConstant, Delay: 20 * time.Millisecond, MaxElapsed: 50 * time.Millisecond}
+		calls := 0
+		err := p.Do(ctx, func(ctx context.Context, attempt int) error {
+			calls++
+			return ErrClickTimeout
+		})
+		if !errors.Is(err, ErrClickTimeout) || calls > 3 {
+			t.Errorf("err = %v after %d calls, want to give up within 50ms", err, calls)
+		}
+	})
+
+	t.Run("cancelled while waiting", func(t *testing.T) {
+		p := RetryPolicy{Attempts: 3, Backoff: BackoffConstant, Delay: time.Hour}
+		ctx, cancel := context.WithCancel(ctx)
+		time.AfterFunc(20*time.Millisecond, cancel)
+
+		start := time.Now()
+		err := p.Do(ctx, func(ctx context.Context, attempt int) error {
+			return ErrClickTimeout
+		})
+		if !errors.Is(err, ErrClickTimeout) || time.Since(start) > time.Second {
+			t.Errorf("err = %v after %s, want to return on cancel", err, time.Since(start))
+		}
+	})
+}
@@ -0,0 +1,93 @@
+package region
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Currency holds the rules for reading prices shown on a Shopee site
+type Currency struct {
+	Code        string
+	Symbol      string
+	MinorDigits int
+	Thousands   string
+	Decimal     string
+}
+
+// Parse converts a displayed price such as "฿1,234.50" into minor units
+func (c Currency) Parse(text string) (int64, error) {
+	s := strings.TrimSpace(text)
+	s = strings.TrimPrefix(s, c.Symbol)
+	s = strings.TrimSuffix(s, c.Symbol)
+	s = strings.TrimFunc(s, func(r rune) bool {
+		return !unicode.IsDigit(r)
+	})
+	if s == "" {
+		return 0, fmt.Errorf("no amount in %q", text)
+	}
+
+	s = strings.ReplaceAll(s, c.Thousands, "")
+	whole, frac, _ := strings.Cut(s, c.Decimal)
+
+	var amount int64
+	for _, r := range whole {
+		if !unicode.IsDigit(r) {
+			return 0, fmt.Errorf("invalid %s amount %q", c.Code, text)
+		}
+		amount = amount*10 + int64(r-'0')
+	}
+
+	if len(frac) > c.MinorDigits {
+		return 0, fmt.Errorf("invalid %s amount %q: too many decimals", c.Code, text)
+	}
+	frac += strings.Repeat("0", c.MinorDigits-len(frac))
+	for _, r := range frac {
+		if !unicode.IsDigit(r) {
+			return 0, fmt.Errorf("invalid %s amount %q", c.Code, text)
+		}
+		amount = amount*10 + int64(r-'0')
+	}
+
+	return amount, nil
+}
+
+// Format renders an amount in minor units the way the site displays it
+func (c Currency) Format(amount int64) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	unit := int64(1)
+	for i := 0; i < c.MinorDigits; i++ {
+		unit *= 10
+	}
+
+	whole := fmt.Sprintf("%d", amount/unit)
+	var b strings.Builder
+	for i, r := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteString(c.Thousands)
+		}
+		b.WriteRune(r)
+	}
+
+	if c.MinorDigits > 0 && amount%unit != 0 {
+		b.WriteString(c.Decimal)
+		b.WriteString(fmt.Sprintf("%0*d", c.MinorDigits, amount%unit))
+	}
+
+	return sign + c.Symbol + b.String()
+}
+
+// CurrencyByCode returns the currency rules for an ISO code such as "THB"
+func CurrencyByCode(code string) (Currency, bool) {
+	for _, r := range regions {
+		if r.Currency.Code == code {
+			return r.Currency, true
+		}
+	}
+	return Currency{}, false
+}
@@ -0,0 +1,190 @@
+package region
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/LLionNg/shopee-livestream-bot/internal/selectors"
+)
+
+// Region describes one Shopee site and its locale-specific rules
+type Region struct {
+	Code      string
+	Name      string
+	Domain    string
+	LoginPath string
+	Currency  Currency
+	// Texts maps a logical element (see the selectors package) to the
+	// button/banner captions the site shows for it, used when no selector matches
+	Texts map[string][]string
+}
+
+// Text keys that are not selector elements
+const (
+	TextSoldOut = "sold_out"
+)
+
+// BaseURL returns the site root, e.g. https://shopee.co.th
+func (r Region) BaseURL() string {
+	return "https://" + r.Domain
+}
+
+// APIURL returns the v4 API root for the site
+func (r Region) APIURL() string {
+	return r.BaseURL() + "/api/v4"
+}
+
+// ImageBaseURL returns the CDN prefix for product image IDs
+func (r Region) ImageBaseURL() string {
+	return "https://down-" + r.Code + ".img.susercontent.com/file/"
+}
+
+// DefaultSelectorsFile is the selector profile for regions without their own
+const DefaultSelectorsFile = "configs/selectors/default.yaml"
+
+// SelectorsFile returns the default selector profile path for the region:
+// configs/selectors/<code>.yaml when the site needs its own selectors,
+// otherwise DefaultSelectorsFile
+func (r Region) SelectorsFile() string {
+	path := "configs/selectors/" + r.Code + ".yaml"
+	if _, err := os.Stat(path); err == nil {
+		return path
+	}
+	return DefaultSelectorsFile
+}
+
+// MatchesURL reports whether rawURL points at this region's site.
+// Local hosts are accepted so the bot can run against locally served pages.
+func (r Region) MatchesURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+
+	host := u.Hostname()
+	if host == "localhost" || host == "127.0.0.1" || host == "::1" {
+		return true
+	}
+	return host == r.Domain || host == "www."+r.Domain
+}
+
+var regions = map[string]Region{
+	"th": {
+		Code:      "th",
+		Name:      "Thailand",
+		Domain:    "shopee.co.th",
+		LoginPath: "/buyer/login",
+		Currency:  Currency{Code: "THB", Symbol: "฿", MinorDigits: 2, Thousands: ",", Decimal: "."},
+		Texts: map[string][]string{
+			selectors.AddToCart:          {"เพิ่มไปยังรถเข็น", "ซื้อสินค้า", "Add To Cart", "Buy Now"},
+			selectors.FlashSaleCountdown: {"Flash Sale", "สิ้นสุดใน"},
+			TextSoldOut:                  {"สินค้าหมด", "หมดแล้ว", "Sold Out"},
+		},
+	},
+	"vn": {
+		Code:      "vn",
+		Name:      "Vietnam",
+		Domain:    "shopee.vn",
+		LoginPath: "/buyer/login",
+		Currency:  Currency{Code: "VND", Symbol: "₫", MinorDigits: 0, Thousands: ".", Decimal: ","},
+		Texts: map[string][]string{
+			selectors.AddToCart:          {"Thêm vào giỏ hàng", "Mua ngay"},
+			selectors.FlashSaleCountdown: {"Flash Sale", "Kết thúc trong"},
+			TextSoldOut:                  {"Hết hàng", "Đã bán hết"},
+		},
+	},
+	"my": {
+		Code:      "my",
+		Name:      "Malaysia",
+		Domain:    "shopee.com.my",
+		LoginPath: "/buyer/login",
+		Currency:  Currency{Code: "MYR", Symbol: "RM", MinorDigits: 2, Thousands: ",", Decimal: "."},
+		Texts: map[string][]string{
+			selectors.AddToCart:          {"Add To Cart", "Buy Now"},
+			selectors.FlashSaleCountdown: {"Flash Sale", "Ends in"},
+			TextSoldOut:                  {"Sold Out"},
+		},
+	},
+	"ph": {
+		Code:      "ph",
+		Name:      "Philippines",
+		Domain:    "shopee.ph",
+		LoginPath: "/buyer/login",
+		Currency:  Currency{Code: "PHP", Symbol: "₱", MinorDigits: 2, Thousands: ",", Decimal: "."},
+		Texts: map[string][]string{
+			selectors.AddToCart:          {"Add To Cart", "Buy Now"},
+			selectors.FlashSaleCountdown: {"Flash Sale", "Ends in"},
+			TextSoldOut:                  {"Sold Out"},
+		},
+	},
+	"sg": {
+		Code:      "sg",
+		Name:      "Singapore",
+		Domain:    "shopee.sg",
+		LoginPath: "/buyer/login",
+		Currency:  Currency{Code: "SGD", Symbol: "$", MinorDigits: 2, Thousands: ",", Decimal: "."},
+		Texts: map[string][]string{
+			selectors.AddToCart:          {"Add To Cart", "Buy Now"},
+			selectors.FlashSaleCountdown: {"Flash Sale", "Ends in"},
+			TextSoldOut:                  {"Sold Out"},
+		},
+	},
+	"id": {
+		Code:      "id",
+		Name:      "Indonesia",
+		Domain:    "shopee.co.id",
+		LoginPath: "/buyer/login",
+		Currency:  Currency{Code: "IDR", Symbol: "Rp", MinorDigits: 0, Thousands: ".", Decimal: ","},
+		Texts: map[string][]string{
+			selectors.AddToCart:          {"Masukkan Keranjang", "Beli Sekarang"},
+			selectors.FlashSaleCountdown: {"Flash Sale", "Berakhir dalam"},
+			TextSoldOut:                  {"Habis", "Stok Habis"},
+		},
+	},
+	"tw": {
+		Code:      "tw",
+		Name:      "Taiwan",
+		Domain:    "shopee.tw",
+		LoginPath: "/buyer/login",
+		Currency:  Currency{Code: "TWD", Symbol: "$", MinorDigits: 0, Thousands: ",", Decimal: "."},
+		Texts: map[string][]string{
+			selectors.AddToCart:          {"加入購物車", "直接購買"},
+			selectors.FlashSaleCountdown: {"限時特賣", "結束於"},
+			TextSoldOut:                  {"已售完", "售完"},
+		},
+	},
+	"br": {
+		Code:      "br",
+		Name:      "Brazil",
+		Domain:    "shopee.com.br",
+		LoginPath: "/buyer/login",
+		Currency:  Currency{Code: "BRL", Symbol: "R$", MinorDigits: 2, Thousands: ".", Decimal: ","},
+		Texts: map[string][]string{
+			selectors.AddToCart:          {"Adicionar ao carrinho", "Comprar agora"},
+			selectors.FlashSaleCountdown: {"Ofertas Relâmpago", "Termina em"},
+			TextSoldOut:                  {"Esgotado"},
+		},
+	},
+}
+
+// Lookup returns the region for a code such as "th"
+func Lookup(code string) (Region, error) {
+	r, ok := regions[strings.ToLower(code)]
+	if !ok {
+		return Region{}, fmt.Errorf("unknown region %q (supported: %s)", code, strings.Join(Codes(), ", "))
+	}
+	return r, nil
+}
+
+// Codes returns all supported region codes in sorted order
+func Codes() []string {
+	codes := make([]string, 0, len(regions))
+	for code := range regions {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
@@ -0,0 +1,343 @@
+package selectors
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/chromedp/chromedp"
+	"gopkg.in/yaml.v3"
+)
+
+// Logical page elements looked up through a selector profile
+const (
+	AddToCart          = "add_to_cart"
+	FlashSaleCountdown = "flash_sale_countdown"
+	ReservationTimer   = "reservation_timer"
+	ProductName        = "product_name"
+	ProductPrice       = "product_price"
+	ProductOrigPrice   = "product_original_price"
+	ProductDiscount    = "product_discount"
+	ProductStock       = "product_stock"
+	ProductCard        = "product_card"
+	ProductSold        = "product_sold"
+	ProductRating      = "product_rating"
+	LoginUsername      = "login_username"
+	LoginPassword      = "login_password"
+	LoginSubmit        = "login_submit"
+	AccountMenu        = "account_menu"
+	LoggedInMarkers    = "logged_in_markers"
+	VerifyCodeInput    = "verify_code_input"
+	VerifyCodeSubmit   = "verify_code_submit"
+	VerifyEmailNotice  = "verify_email_notice"
+	CartCount          = "cart_count"
+	CartSelectAll      = "cart_select_all"
+	CartDelete         = "cart_delete"
+	CartConfirm        = "cart_confirm"
+	CartItem           = "cart_item"
+	CartItemName       = "cart_item_name"
+	CartItemVariant    = "cart_item_variant"
+	CartItemPrice      = "cart_item_price"
+	CartItemQuantity   = "cart_item_quantity"
+	CartItemReserved   = "cart_item_reserved"
+	CartItemRemove     = "cart_item_remove"
+	CartShopName       = "cart_shop_name"
+	CartItemSelect     = "cart_item_select"
+	CartCheckout       = "cart_checkout"
+	CheckoutAddress    = "checkout_address"
+	CheckoutShipping   = "checkout_shipping"
+	CheckoutPayment    = "checkout_payment"
+	CheckoutPayOption  = "checkout_payment_option"
+	CheckoutTotal      = "checkout_total"
+	CheckoutPlaceOrder = "checkout_place_order"
+	OrderID            = "order_id"
+)
+
+// Elements lists every logical element a profile must define
+var Elements = []string{
+	AddToCart,
+	FlashSaleCountdown,
+	ReservationTimer,
+	ProductName,
+	ProductPrice,
+	ProductOrigPrice,
+	ProductDiscount,
+	ProductStock,
+	ProductCard,
+	ProductSold,
+	ProductRating,
+	LoginUsername,
+	LoginPassword,
+	LoginSubmit,
+	AccountMenu,
+	LoggedInMarkers,
+	VerifyCodeInput,
+	VerifyCodeSubmit,
+	VerifyEmailNotice,
+	CartCount,
+	CartSelectAll,
+	CartDelete,
+	CartConfirm,
+	CartItem,
+	CartItemName,
+	CartItemVariant,
+	CartItemPrice,
+	CartItemQuantity,
+	CartItemReserved,
+	CartItemRemove,
+	CartShopName,
+	CartItemSelect,
+	CartCheckout,
+	CheckoutAddress,
+	CheckoutShipping,
+	CheckoutPayment,
+	CheckoutPayOption,
+	CheckoutTotal,
+	CheckoutPlaceOrder,
+	OrderID,
+}
+
+// ErrNotFound is returned when none of an element's selectors match
+var ErrNotFound = errors.New("no selector matched")
+
+// Profile is a versioned set of selectors for one region/site variant, or
+// for every region when Region is empty. Each logical element maps to an
+// ordered list of fallback selectors.
+type Profile struct {
+	Version string `yaml:"version"`
+	Region  string `yaml:"region"`
+	Variant string `yaml:"variant"`
+	// UserAgent is the browser identity the selectors were written
+	// against; empty keeps Chrome's own
+	UserAgent string              `yaml:"user_agent"`
+	Elements  map[string][]string `yaml:"elements"`
+}
+
+// LoadProfile reads and validates a selector profile file
+func LoadProfile(path string) (*Profile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read selector profile: %w", err)
+	}
+
+	var p Profile
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse selector profile %s: %w", path, err)
+	}
+
+	if err := p.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid selector profile %s: %w", path, err)
+	}
+
+	return &p, nil
+}
+
+// Validate checks that every logical element has at least one selector
+func (p *Profile) Validate() error {
+	if p.Version == "" {
+		return fmt.Errorf("version is required")
+	}
+	for _, name := range Elements {
+		if len(p.Elements[name]) == 0 {
+			return fmt.Errorf("element %q has no selectors", name)
+		}
+	}
+	return nil
+}
+
+// Get returns the ordered selectors for an element
+func (p *Profile) Get(name string) []string {
+	return p.Elements[name]
+}
+
+// Store holds the active profile and allows it to be reloaded at runtime
+type Store struct {
+	mu      sync.RWMutex
+	path    string
+	texts   map[string][]string
+	profile *Profile
+}
+
+// NewStore loads the profile at path. texts maps elements to the
+// locale-specific captions used as a fallback when no selector matches.
+func NewStore(path string, texts map[string][]string) (*Store, error) {
+	s := &Store{path: path, texts: texts}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload re-reads the profile file, keeping the old profile on failure
+func (s *Store) Reload() error {
+	p, err := LoadProfile(s.path)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.profile = p
+	s.mu.Unlock()
+	return nil
+}
+
+// Path returns the profile file path
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Profile returns the currently active profile
+func (s *Store) Profile() *Profile {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.profile
+}
+
+// Get returns the ordered selectors for an element in the active profile
+func (s *Store) Get(name string) []string {
+	return s.Profile().Get(name)
+}
+
+// Find returns the first selector for an element that matches on the page,
+// falling back to matching the element's locale-specific caption. A
+// selector that fails (e.g. invalid CSS) is skipped; its error is returned
+// only when every candidate failed.
+func (s *Store) Find(ctx context.Context, name string) (string, error) {
+	var lastErr error
+	tried, failed := 0, 0
+	for _, selector := range s.Get(name) {
+		tried++
+		count, err := Count(ctx, selector)
+		if err != nil {
+			if ctx.Err() != nil {
+				return "", ctx.Err()
+			}
+			failed++
+			lastErr = fmt.Errorf("%s: selector %q: %w", name, selector, err)
+			continue
+		}
+		if count > 0 {
+			return selector, nil
+		}
+	}
+
+	if texts := s.texts[name]; len(texts) > 0 {
+		tried++
+		selector, err := MatchText(ctx, name, texts)
+		if err != nil {
+			if ctx.Err() != nil {
+				return "", ctx.Err()
+			}
+			failed++
+			lastErr = fmt.Errorf("%s: matching captions: %w", name, err)
+		} else if selector != "" {
+			return selector, nil
+		}
+	}
+
+	if tried > 0 && failed == tried {
+		return "", lastErr
+	}
+	return "", fmt.Errorf("%s: %w", name, ErrNotFound)
+}
+
+// MatchText finds a visible button, link or banner whose caption matches one
+// of texts, tags it and returns a selector for it ("" when nothing matches)
+func MatchText(ctx context.Context, name string, texts []string) (string, error) {
+	list, err := json.Marshal(texts)
+	if err != nil {
+		return "", err
+	}
+	quotedName, _ := json.Marshal(name)
+
+	script := fmt.Sprintf(`(() => {
+		const texts = %s.map(t => t.toLowerCase());
+		const name = %s;
+		const groups = ['button, [role="button"], a', '[class*="button"], [class*="banner"]', 'span, div'];
+		for (const group of groups) {
+			for (const el of document.querySelectorAll(group)) {
+				if (!el.offsetParent) continue;
+				const caption = (el.innerText || '').trim().toLowerCase();
+				if (!caption || caption.length > 64) continue;
+				if (texts.some(t => caption === t || caption.startsWith(t))) {
+					el.setAttribute('data-bot-match', name);
+					return '[data-bot-match="' + name + '"]';
+				}
+			}
+		}
+		return '';
+	})()`, list, quotedName)
+
+	var selector string
+	err = chromedp.Run(ctx, chromedp.Evaluate(script, &selector))
+	return selector, err
+}
+
+// Exists reports whether any selector for an element matches on the page
+func (s *Store) Exists(ctx context.Context, name string) bool {
+	_, err := s.Find(ctx, name)
+	return err == nil
+}
+
+// Text returns the text of the first matching selector for an element
+func (s *Store) Text(ctx context.Context, name string) (string, error) {
+	selector, err := s.Find(ctx, name)
+	if err != nil {
+		return "", err
+	}
+
+	var text string
+	if err := chromedp.Run(ctx, chromedp.Text(selector, &text, chromedp.ByQuery)); err != nil {
+		return "", err
+	}
+	return text, nil
+}
+
+// AnyExistsJS returns a JavaScript expression that is true when any of the
+// element's selectors matches
+func (s *Store) AnyExistsJS(name string) string {
+	list, _ := json.Marshal(s.Get(name))
+	return fmt.Sprintf(`%s.some(s => !!document.querySelector(s))`, list)
+}
+
+// Count returns how many elements on the page match a selector
+func Count(ctx context.Context, selector string) (int, error) {
+	quoted, err := json.Marshal(selector)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int
+	err = chromedp.Run(ctx,
+		chromedp.Evaluate(fmt.Sprintf(`document.querySelectorAll(%s).length`, quoted), &count),
+	)
+	return count, err
+}
+
+// Result reports how one selector fared against a page
+type Result struct {
+	Element  string
+	Selector string
+	Matches  int
+	Err      error
+}
+
+// Test evaluates every selector of a profile against the current page
+func Test(ctx context.Context, p *Profile) []Result {
+	var results []Result
+	for _, name := range Elements {
+		for _, selector := range p.Get(name) {
+			count, err := Count(ctx, selector)
+			results = append(results, Result{
+				Element:  name,
+				Selector: selector,
+				Matches:  count,
+				Err:      err,
+			})
+		}
+	}
+	return results
+}
@@ -0,0 +1,64 @@
+package selectors_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/LLionNg/shopee-livestream-bot/internal/browser"
+	"github.com/LLionNg/shopee-livestream-bot/internal/selectors"
+	"github.com/chromedp/chromedp"
+	"gopkg.in/yaml.v3"
+)
+
+// TestFindSkipsBrokenSelectors runs Find against a small page. It needs
+// Chrome and is skipped without it.
+func TestFindSkipsBrokenSelectors(t *testing.T) {
+	execPath, err := browser.FindChrome("")
+	if err != nil {
+		t.Skipf("Chrome not available: %v", err)
+	}
+
+	allocCtx, cancel := chromedp.NewExecAllocator(context.Background(),
+		append(chromedp.DefaultExecAllocatorOptions[:], chromedp.ExecPath(execPath))...)
+	defer cancel()
+	ctx, cancel := chromedp.NewContext(allocCtx)
+	defer cancel()
+	ctx, cancel = context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	if err := chromedp.Run(ctx, chromedp.Navigate(`data:text/html,<button class="buy">Buy</button>`)); err != nil {
+		t.Skipf("Chrome failed to start: %v", err)
+	}
+
+	profile := selectors.Profile{Version: "test", Elements: map[string][]string{}}
+	for _, name := range selectors.Elements {
+		profile.Elements[name] = []string{"div.card"}
+	}
+	profile.Elements[selectors.AddToCart] = []string{"button[", "button.add", "button.buy"}
+	profile.Elements[selectors.ProductName] = []string{"div[", "h1["}
+	data, err := yaml.Marshal(profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "profile.yaml")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := selectors.NewStore(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := s.Find(ctx, selectors.AddToCart); err != nil || got != "button.buy" {
+		t.Errorf("Find past an invalid selector = %q, %v; want button.buy", got, err)
+	}
+	if _, err := s.Find(ctx, selectors.ProductName); err == nil || errors.Is(err, selectors.ErrNotFound) {
+		t.Errorf("Find with only invalid selectors = %v, want their error", err)
+	}
+	if _, err := s.Find(ctx, selectors.ProductCard); !errors.Is(err, selectors.ErrNotFound) {
+		t.Errorf("Find without a match = %v, want ErrNotFound", err)
+	}
+}
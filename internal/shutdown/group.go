@@ -0,0 +1,95 @@
+// Package shutdown tracks the bot's background work so a shutdown can wait
+// for it and say what didn't stop in time.
+package shutdown
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Group is a WaitGroup that knows what is running. The zero value is ready
+// to use; a nil Group runs work untracked.
+type Group struct {
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	running map[string]int
+}
+
+// Add records that the named work started and returns the func to call when
+// it is done
+func (g *Group) Add(name string) (done func()) {
+	if g == nil {
+		return func() {}
+	}
+	g.wg.Add(1)
+	g.mu.Lock()
+	if g.running == nil {
+		g.running = map[string]int{}
+	}
+	g.running[name]++
+	g.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			g.mu.Lock()
+			if g.running[name]--; g.running[name] <= 0 {
+				delete(g.running, name)
+			}
+			g.mu.Unlock()
+			g.wg.Done()
+		})
+	}
+}
+
+// Go runs fn in a goroutine tracked under name
+func (g *Group) Go(name string, fn func()) {
+	done := g.Add(name)
+	go func() {
+		defer done()
+		fn()
+	}()
+}
+
+// Running lists the work still running, e.g. "stream 2" or "checkout (x2)"
+func (g *Group) Running() []string {
+	if g == nil {
+		return nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	names := make([]string, 0, len(g.running))
+	for name, n := range g.running {
+		if n > 1 {
+			name = fmt.Sprintf("%s (x%d)", name, n)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Wait waits up to timeout for all work to finish. It returns what was still
+// running when the time ran out, or nil.
+func (g *Group) Wait(timeout time.Duration) []string {
+	if g == nil {
+		return nil
+	}
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return nil
+	case <-timer.C:
+		return g.Running()
+	}
+}
@@ -0,0 +1,44 @@
+package shutdown
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGroupWait(t *testing.T) {
+	var g Group
+	release := make(chan struct{})
+
+	g.Go("quick", func() {})
+	g.Go("stream 1", func() { <-release })
+	g.Go("checkout", func() { <-release })
+	g.Go("checkout", func() { <-release })
+
+	want := []string{"checkout (x2)", "stream 1"}
+	if got := g.Wait(50 * time.Millisecond); !reflect.DeepEqual(got, want) {
+		t.Errorf("still running = %v, want %v", got, want)
+	}
+
+	close(release)
+	if got := g.Wait(time.Second); got != nil {
+		t.Errorf("still running after release = %v", got)
+	}
+}
+
+func TestGroupAddDoneOnce(t *testing.T) {
+	var g Group
+	done := g.Add("login")
+	done()
+	done() // a second call must not unbalance the group
+
+	if got := g.Wait(time.Second); got != nil {
+		t.Errorf("still running = %v", got)
+	}
+
+	var nilGroup *Group
+	nilGroup.Add("x")()
+	if got := nilGroup.Wait(time.Millisecond); got != nil {
+		t.Errorf("nil group running = %v", got)
+	}
+}
@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"io"
 	"log/slog"
 	"os"
 )
@@ -12,6 +13,11 @@ type Logger struct {
 
 // New creates a new logger instance
 func New(level string, console bool) *Logger {
+	return NewWriter(os.Stdout, level, console)
+}
+
+// NewWriter creates a logger writing to w
+func NewWriter(w io.Writer, level string, console bool) *Logger {
 	var logLevel slog.Level
 	switch level {
 	case "debug":
@@ -32,9 +38,9 @@ func New(level string, console bool) *Logger {
 
 	var handler slog.Handler
 	if console {
-		handler = slog.NewTextHandler(os.Stdout, opts)
+		handler = slog.NewTextHandler(w, opts)
 	} else {
-		handler = slog.NewJSONHandler(os.Stdout, opts)
+		handler = slog.NewJSONHandler(w, opts)
 	}
 
 	logger := slog.New(handler)
@@ -67,6 +73,11 @@ func (l *Logger) Fatal(msg string, args ...any) {
 	os.Exit(1)
 }
 
+// Slog returns the underlying slog logger
+func (l *Logger) Slog() *slog.Logger {
+	return l.logger
+}
+
 // With returns a new logger with additional context
 func (l *Logger) With(args ...any) *Logger {
 	return &Logger{logger: l.logger.With(args...)}